@@ -0,0 +1,159 @@
+// Package asm translates the Hack assembly into the Hack machine code.
+package asm
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ProchazkaDavid/nand2tetris/assembler/rom"
+)
+
+// Options configures the assembly.
+type Options struct {
+	// Open opens the files included by the .include directive, os.Open is used if nil.
+	Open func(filename string) (io.ReadCloser, error)
+	// Strict rejects unknown symbols in A-instructions instead of declaring new variables.
+	// Variables have to be declared by the .var and .block directives.
+	Strict bool
+	// Optimize runs the peephole optimizer on the program before the translation, see Optimize.
+	Optimize bool
+	// RAM configures how variables allocated outside of the static region 16-255 are reported.
+	RAM RAMCheck
+}
+
+// opener returns the Open, or os.Open if the Open is nil
+func (o Options) opener() func(filename string) (io.ReadCloser, error) {
+	if o.Open != nil {
+		return o.Open
+	}
+
+	return func(filename string) (io.ReadCloser, error) { return os.Open(filename) }
+}
+
+// Program represents the assembled program.
+type Program struct {
+	// Words contains the machine code, one instruction per word
+	Words []uint16
+	// Symbols contains the final symbol table including labels and allocated variables
+	Symbols SymbolTable
+	// Listing contains every instruction and label of the program with its source line
+	Listing []ListingLine
+	// Optimized contains statistics of the peephole optimizer if enabled by the options
+	Optimized OptimizeStats
+	// Warnings contains variables allocated outside of the static region, see RAMCheck
+	Warnings ErrorList
+	// variables contains the RAM blocks of the variables
+	variables []ramBlock
+}
+
+// ListingLine represents the instruction or label at the ROM address with its source line.
+type ListingLine struct {
+	Address uint16
+	// Instruction is false for labels, which don't occupy the ROM
+	Instruction bool
+	Word        uint16
+	Pos         Position
+	Text        string
+}
+
+// Assemble translates the assembly read from the input into the machine code.
+// The filename is used in positions of errors and to resolve included files.
+// All errors found in the input are returned at once as the ErrorList.
+func Assemble(input io.Reader, filename string, options Options) (*Program, error) {
+	return assemble(input, filename, options, nil)
+}
+
+// assemble translates the assembly into the machine code. If the module isn't nil,
+// the relocation information of the object file is collected into the module.
+func assemble(input io.Reader, filename string, options Options, module *objectModule) (*Program, error) {
+	pp := newPreprocessor(options.opener())
+	lines, err := pp.read(input, filename, 0)
+	if err != nil {
+		return nil, fmt.Errorf("can't read %s: %w", filename, err)
+	}
+
+	var optimized OptimizeStats
+	if options.Optimize && len(pp.errors) == 0 {
+		var errors ErrorList
+		lines, optimized, errors = optimize(lines)
+		pp.errors = append(pp.errors, errors...)
+	}
+
+	p := newParser(lines, pp.errors, options.Strict)
+	p.object = module
+
+	// First pass - populates the symbol table
+	symbols, fits := p.parseSymbols()
+	if !fits {
+		return nil, p.errors.err()
+	}
+
+	program := &Program{Symbols: symbols, Optimized: optimized}
+	if module != nil {
+		p.checkExports()
+	}
+
+	// Second pass - translates the commands into the final binary code
+	for p.hasMoreCommands() {
+		p.advance()
+
+		line := ListingLine{Address: uint16(len(program.Words)), Pos: p.pos, Text: p.text}
+
+		switch p.commandType() {
+		case aCommand:
+			line.Word, line.Instruction = translateACommand(p, program.Symbols)
+		case cCommand:
+			line.Word, line.Instruction = translateCCommand(p)
+		}
+
+		if line.Instruction {
+			program.Words = append(program.Words, line.Word)
+
+			if module != nil {
+				module.words = append(module.words, ObjectWord{line.Word, p.reference.Relocation, p.reference.Symbol})
+			}
+		}
+
+		program.Listing = append(program.Listing, line)
+	}
+
+	// Variables of the object files are allocated by the linker
+	if module == nil {
+		warnings, errors := checkRAM(p.blocks, options.RAM)
+		p.errors = append(p.errors, errors...)
+		program.Warnings, program.variables = warnings, p.blocks
+	}
+
+	if err := p.errors.err(); err != nil {
+		return nil, err
+	}
+
+	return program, nil
+}
+
+// AssembleString translates the assembly source into the machine code.
+func AssembleString(source, filename string, options Options) (*Program, error) {
+	return Assemble(strings.NewReader(source), filename, options)
+}
+
+// AssembleFile translates the .asm file into the machine code.
+func AssembleFile(filename string, options Options) (*Program, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Assemble(f, filename, options)
+}
+
+// WriteHack writes the machine code in the .hack format, one instruction
+// written as 16 binary digits per line.
+func (p *Program) WriteHack(w io.Writer) error { return rom.Write(w, p.Words, rom.Hack) }
+
+// WriteFormat writes the machine code in the given format, see the rom package.
+func (p *Program) WriteFormat(w io.Writer, format rom.Format) error {
+	return rom.Write(w, p.Words, format)
+}
@@ -0,0 +1,124 @@
+package asm
+
+import (
+	"strconv"
+	"strings"
+)
+
+// getDestBinary returns the binary code of the getDestBinary mnemonic.
+// The ok is false if the mnemonic is unknown.
+func getDestBinary(mnemonic string) (binary uint16, ok bool) {
+	binary, ok = destToBinary[mnemonic]
+	return binary, ok
+}
+
+// getCompBinary returns the binary code of the getCompBinary mnemonic.
+// Operands of the commutative operators + & | may be swapped, e.g. A+D or 1+M,
+// and the raw comp bits may be given, see rawCompBits.
+// The ok is false if the mnemonic is unknown.
+func getCompBinary(mnemonic string) (binary uint16, ok bool) {
+	if binary, ok = compToBinary[mnemonic]; ok {
+		return binary, true
+	}
+
+	if isRawComp(mnemonic) {
+		return rawCompBits(mnemonic)
+	}
+
+	binary, ok = compToBinary[swapOperands(mnemonic)]
+	return binary, ok
+}
+
+// isRawComp checks if the comp mnemonic is written as the raw bits
+func isRawComp(mnemonic string) bool { return strings.HasPrefix(mnemonic, "comp(") }
+
+// rawCompBits returns the comp bits (a, c1, c2, c3, c4, c5, c6) written as comp(0b...) with
+// exactly 7 binary digits, e.g. comp(0b0111110) computes -2. The ok is false otherwise.
+// The escape keeps the binary constants of D=0b... pseudo-instructions unambiguous.
+func rawCompBits(mnemonic string) (binary uint16, ok bool) {
+	if len(mnemonic) != len("comp(0b)")+7 || !strings.HasPrefix(mnemonic, "comp(0b") || !strings.HasSuffix(mnemonic, ")") {
+		return 0, false
+	}
+
+	value, err := strconv.ParseUint(mnemonic[len("comp(0b"):len(mnemonic)-1], 2, 7)
+	return uint16(value), err == nil
+}
+
+// swapOperands returns the Y op X form of the X op Y mnemonic of the commutative operator,
+// or the mnemonic itself if it has a different form
+func swapOperands(mnemonic string) string {
+	for _, op := range []string{"+", "&", "|"} {
+		operands := strings.Split(mnemonic, op)
+		if len(operands) != 2 || operands[0] == "" || operands[1] == "" {
+			continue
+		}
+
+		if strings.ContainsAny(operands[0], "+-&|!") || strings.ContainsAny(operands[1], "+-&|!") {
+			continue
+		}
+
+		return operands[1] + op + operands[0]
+	}
+
+	return mnemonic
+}
+
+// getJumpBinary returns the binary code of the getJumpBinary mnemonic.
+// The ok is false if the mnemonic is unknown.
+func getJumpBinary(mnemonic string) (binary uint16, ok bool) {
+	binary, ok = jumpToBinary[mnemonic]
+	return binary, ok
+}
+
+var destToBinary = map[string]uint16{
+	"":    0b000,
+	"M":   0b001,
+	"D":   0b010,
+	"MD":  0b011,
+	"A":   0b100,
+	"AM":  0b101,
+	"AD":  0b110,
+	"AMD": 0b111,
+}
+
+var compToBinary = map[string]uint16{
+	"0":   0b0101010,
+	"1":   0b0111111,
+	"-1":  0b0111010,
+	"D":   0b0001100,
+	"A":   0b0110000,
+	"!D":  0b0001101,
+	"!A":  0b0110001,
+	"-D":  0b0001111,
+	"-A":  0b0110011,
+	"D+1": 0b0011111,
+	"A+1": 0b0110111,
+	"D-1": 0b0001110,
+	"A-1": 0b0110010,
+	"D+A": 0b0000010,
+	"D-A": 0b0010011,
+	"A-D": 0b0000111,
+	"D&A": 0b0000000,
+	"D|A": 0b0010101,
+	"M":   0b1110000,
+	"!M":  0b1110001,
+	"-M":  0b1110011,
+	"M+1": 0b1110111,
+	"M-1": 0b1110010,
+	"D+M": 0b1000010,
+	"D-M": 0b1010011,
+	"M-D": 0b1000111,
+	"D&M": 0b1000000,
+	"D|M": 0b1010101,
+}
+
+var jumpToBinary = map[string]uint16{
+	"":    0b000,
+	"JGT": 0b001,
+	"JEQ": 0b010,
+	"JGE": 0b011,
+	"JLT": 0b100,
+	"JNE": 0b101,
+	"JLE": 0b110,
+	"JMP": 0b111,
+}
@@ -0,0 +1,72 @@
+package asm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGetCompBinary(t *testing.T) {
+	tests := []struct {
+		mnemonic string
+		binary   uint16
+		ok       bool
+	}{
+		{"D+A", 0b0000010, true},
+		{"A+D", 0b0000010, true},
+		{"M&D", 0b1000000, true},
+		{"M|D", 0b1010101, true},
+		{"1+D", 0b0011111, true},
+		{"1+M", 0b1110111, true},
+		{"A-D", 0b0000111, true},
+		{"comp(0b0111110)", 0b0111110, true},
+		{"comp(0b1111111)", 0b1111111, true},
+		// Subtraction isn't commutative and -1 isn't a swappable operand
+		{"D-M", 0b1010011, true},
+		{"A-M", 0, false},
+		{"1-D", 0, false},
+		{"D+A+1", 0, false},
+		{"0b0111110", 0, false},
+		{"comp(0b011111)", 0, false},
+		{"comp(0b01111102)", 0, false},
+		{"comp(0x3E)", 0, false},
+	}
+
+	for _, test := range tests {
+		binary, ok := getCompBinary(test.mnemonic)
+		if ok != test.ok || binary != test.binary {
+			t.Errorf("getCompBinary(%q) = %07b, %v, expected %07b, %v", test.mnemonic, binary, ok, test.binary, test.ok)
+		}
+	}
+}
+
+func TestRawCompBits(t *testing.T) {
+	program, err := AssembleString("D=comp(0b0111110)\nAM = comp( 0b0000011 ) ; JGT\nD=0b0000011\nD=0b1111\n", "test.asm", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Binary numbers without comp(...) are the constants of D=<const> regardless of their digits
+	expected := []uint16{0xEF90, 0xE0E9, 3, 0xEC10, 15, 0xEC10}
+	if !reflect.DeepEqual(program.Words, expected) {
+		t.Errorf("assembled %04X, expected %04X", program.Words, expected)
+	}
+}
+
+func TestRawCompBitsErrors(t *testing.T) {
+	tests := []struct {
+		source string
+		err    string
+	}{
+		{"D=comp(0b101)\n", `test.asm:1:3: raw comp bits "comp(0b101)" must be comp(0b...) with 7 binary digits`},
+		{"D=comp(0b0111110\n", `test.asm:1:3: raw comp bits "comp(0b0111110" must be comp(0b...) with 7 binary digits`},
+		{"A=(D)\n", `test.asm:1:3: unexpected "(" in C-instruction`},
+	}
+
+	for _, test := range tests {
+		_, err := AssembleString(test.source, "test.asm", Options{})
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("assembling %q: got error %v, expected %q", test.source, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package asm
+
+import "strings"
+
+// parseDirective processes the directive at the current line during the first pass.
+//   .equ NAME value       - declares the constant NAME
+//   .var NAME [address]   - declares the variable NAME, placed at the address if given
+//   .block NAME size      - declares the variable NAME followed by size-1 reserved words
+//   .export LABEL         - makes the LABEL of the module available to other modules
+//   .import LABEL         - declares the LABEL exported by another module
+// The value, address and size are constant expressions which can refer
+// to the symbols declared above the directive. The .export is ignored
+// and .import is rejected unless the module is assembled into the object file.
+func (p *parser) parseDirective(table SymbolTable) {
+	fields := strings.Fields(p.command)
+	name := ""
+	if len(fields) > 1 {
+		name = fields[1]
+	}
+
+	argument := ""
+	if len(fields) > 2 {
+		rest := strings.TrimSpace(p.command[len(fields[0]):])
+		argument = strings.TrimSpace(rest[len(name):])
+	}
+
+	switch fields[0] {
+	case ".equ", ".var", ".block", ".export", ".import":
+	default:
+		p.errorf("unknown directive %s", fields[0])
+		return
+	}
+
+	if !isValidSymbol(name) {
+		p.errorf("expected %s NAME, got %q", fields[0], name)
+		return
+	}
+
+	switch fields[0] {
+	case ".export", ".import":
+		p.parseLinkage(table, fields[0], name, argument)
+
+	case ".equ":
+		if value, ok := p.evaluateArgument(table, fields[0], argument); ok {
+			p.declare(table, name, Symbol{value, Constant})
+		}
+
+	case ".var":
+		if argument == "" {
+			p.declareVariable(table, name, 1)
+			return
+		}
+
+		address, ok := p.evaluateArgument(table, fields[0], argument)
+		if !ok {
+			return
+		}
+
+		if p.reserved[address] {
+			p.errorf("RAM address %d of variable %s is already reserved", address, name)
+			return
+		}
+
+		p.place(p.pos, name, address)
+		if p.declare(table, name, Symbol{address, Variable}) && p.object != nil {
+			p.object.variables = append(p.object.variables, ObjectVariable{Name: name, Size: 1, Fixed: true, Address: address})
+		}
+
+	case ".block":
+		size, ok := p.evaluateArgument(table, fields[0], argument)
+		if !ok {
+			return
+		}
+
+		if size == 0 {
+			p.errorf("size of block %s must be positive", name)
+			return
+		}
+
+		p.declareVariable(table, name, int(size))
+	}
+}
+
+// parseLinkage processes the .export and .import directives of the object file
+func (p *parser) parseLinkage(table SymbolTable, directive, name, argument string) {
+	if argument != "" {
+		p.errorf("unexpected %q after %s %s", argument, directive, name)
+		return
+	}
+
+	switch {
+	case p.object == nil && directive == ".import":
+		p.errorf(".import is allowed only in modules assembled into object files")
+	case p.object == nil:
+		// Single file programs have nothing to export to
+	case directive == ".export":
+		if _, ok := p.object.exports[name]; !ok {
+			p.object.exports[name] = p.pos
+		}
+	case p.declare(table, name, Symbol{0, Label}):
+		p.object.relocations[name] = RelocateImport
+		p.object.imports = append(p.object.imports, name)
+	}
+}
+
+// declareVariable declares the variable of the given size. The variable is allocated
+// right away, or by the linker if the module is assembled into the object file.
+func (p *parser) declareVariable(table SymbolTable, name string, size int) {
+	if p.object == nil {
+		p.declare(table, name, Symbol{p.allocate(p.pos, name, size), Variable})
+		return
+	}
+
+	if p.declare(table, name, Symbol{0, Variable}) {
+		p.object.relocations[name] = RelocateVariable
+		p.object.variables = append(p.object.variables, ObjectVariable{Name: name, Size: size})
+	}
+}
+
+// evaluateArgument returns the value of the constant expression argument of the directive.
+// Errors are recorded and ok is false.
+func (p *parser) evaluateArgument(table SymbolTable, directive, argument string) (value uint16, ok bool) {
+	if argument == "" {
+		p.errorf("missing argument of %s", directive)
+		return 0, false
+	}
+
+	result, err := evaluate(argument, p.resolver(table))
+	if err != nil {
+		p.errorf("%v", err)
+		return 0, false
+	}
+
+	if result < 0 || result > maxConstant {
+		p.errorf("value %d of %q is outside of the range 0-%d", result, argument, maxConstant)
+		return 0, false
+	}
+
+	return uint16(result), true
+}
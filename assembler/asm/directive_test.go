@@ -0,0 +1,90 @@
+package asm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDirectives(t *testing.T) {
+	source := `.equ ROWS 4
+.equ SIZE ROWS*2
+.var fixed 17
+.block buffer SIZE
+.var count
+.var cursor SIZE+100
+@x
+@y
+`
+
+	program, err := AssembleString(source, "test.asm", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The block skips the fixed variable, the following variables continue after the block
+	expected := map[string]Symbol{
+		"ROWS":   {4, Constant},
+		"SIZE":   {8, Constant},
+		"fixed":  {17, Variable},
+		"buffer": {18, Variable},
+		"count":  {26, Variable},
+		"cursor": {108, Variable},
+		"x":      {27, Variable},
+		"y":      {28, Variable},
+	}
+	for name, symbol := range expected {
+		if program.Symbols[name] != symbol {
+			t.Errorf("symbol %s is %+v, expected %+v", name, program.Symbols[name], symbol)
+		}
+	}
+}
+
+func TestDirectiveErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		err    string
+	}{
+		{"duplicate constant", ".equ A 1\n.equ A 2\n", `test.asm:2: duplicate symbol "A", previously declared at test.asm:1`},
+		{"label redefining constant", ".equ LOOP 1\n(LOOP)\n", `test.asm:2: duplicate symbol "LOOP", previously declared at test.asm:1`},
+		{"predefined symbol", ".var SCREEN\n", `test.asm:1: symbol "SCREEN" redefines a predefined symbol`},
+		{"reserved address", ".var a 20\n.var b 20\n", "test.asm:2: RAM address 20 of variable b is already reserved"},
+		{"empty block", ".block b 0\n", "test.asm:1: size of block b must be positive"},
+		{"missing argument", ".equ A\n", "test.asm:1: missing argument of .equ"},
+		{"constant declared below", ".equ A B\n.equ B 1\n", `undefined symbol "B"`},
+		{"unknown directive", ".org 100\n", "test.asm:1: unknown directive .org"},
+		{"invalid name", ".equ 1A 2\n", `test.asm:1: expected .equ NAME, got "1A"`},
+		{"import outside of module", ".import Lib\n", "test.asm:1: .import is allowed only in modules assembled into object files"},
+		{"block outside of the RAM", ".block huge 0x7FFF\n@huge\n", "variable huge of 32767 words doesn't fit into the RAM"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := AssembleString(test.source, "test.asm", Options{})
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("got error %v, expected %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestStrict(t *testing.T) {
+	source := ".var count\n.equ LIMIT 10\n(LOOP)\n@count\n@LIMIT\n@R5\n@LOOP\n@cuont\nD=A\n@undefined\n"
+
+	_, err := AssembleString(source, "test.asm", Options{Strict: true})
+	expected := `test.asm:8: undefined symbol "cuont", implicit variables are disabled` + "\n" +
+		`test.asm:10: undefined symbol "undefined", implicit variables are disabled`
+	if err == nil || err.Error() != expected {
+		t.Errorf("got error %v, expected %s", err, expected)
+	}
+
+	// The same program declares the variables implicitly without the strict mode
+	program, err := AssembleString(source, "test.asm", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if program.Symbols["cuont"] != (Symbol{17, Variable}) {
+		t.Errorf("symbol cuont is %+v, expected the variable at 17", program.Symbols["cuont"])
+	}
+}
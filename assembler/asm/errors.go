@@ -0,0 +1,84 @@
+package asm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Error represents an error at the specific line of the .asm file.
+type Error struct {
+	Pos     Position
+	Message string
+}
+
+// Error returns the error prefixed by the file:line position. Errors inside
+// of macros are reported at the outermost call site followed by the chain
+// of the expanded macros and their definition lines.
+func (e *Error) Error() string {
+	pos := e.Pos
+
+	var expansions []string
+	for pos.Caller != nil {
+		expansions = append([]string{fmt.Sprintf("in macro %s at %s", pos.Macro, pos)}, expansions...)
+		pos = *pos.Caller
+	}
+
+	if len(expansions) == 0 {
+		return fmt.Sprintf("%s: %s", pos, e.Message)
+	}
+
+	return fmt.Sprintf("%s: %s (%s)", pos, e.Message, strings.Join(expansions, ", "))
+}
+
+// ErrorList collects all errors found in the .asm file.
+type ErrorList []*Error
+
+func (l ErrorList) Error() string {
+	messages := make([]string, 0, len(l))
+	for _, err := range l {
+		messages = append(messages, err.Error())
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+// err returns the list sorted by the positions as an error, or nil if the list is empty.
+func (l ErrorList) err() error {
+	if len(l) == 0 {
+		return nil
+	}
+
+	l.sort()
+	return l
+}
+
+// sort sorts the errors by the reported positions, which are the outermost macro calls.
+// Errors of the passes are collected separately, so they are merged into the line order.
+// Files are ordered by their first error.
+func (l ErrorList) sort() {
+	reported := func(pos Position) Position {
+		for pos.Caller != nil {
+			pos = *pos.Caller
+		}
+		return pos
+	}
+
+	order := map[string]int{}
+	for _, err := range l {
+		if filename := reported(err.Pos).Filename; order[filename] == 0 {
+			order[filename] = len(order) + 1
+		}
+	}
+
+	sort.SliceStable(l, func(i, j int) bool {
+		a, b := reported(l[i].Pos), reported(l[j].Pos)
+		if a.Filename != b.Filename {
+			return order[a.Filename] < order[b.Filename]
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+}
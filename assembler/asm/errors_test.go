@@ -0,0 +1,33 @@
+package asm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestErrorsInLineOrder(t *testing.T) {
+	source := `D=Q
+(X)
+(X)
+.macro BAD
+D=Q
+.endm
+BAD
+.equ
+`
+
+	_, err := AssembleString(source, "test.asm", Options{})
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+
+	var lines []string
+	for _, message := range strings.Split(err.Error(), "\n") {
+		lines = append(lines, message[:strings.Index(message, ": ")])
+	}
+
+	expected := []string{"test.asm:1:3", "test.asm:3", "test.asm:7", "test.asm:8"}
+	if strings.Join(lines, " ") != strings.Join(expected, " ") {
+		t.Errorf("errors at %v, expected %v\n%s", lines, expected, err)
+	}
+}
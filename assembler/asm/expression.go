@@ -0,0 +1,174 @@
+package asm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// expression evaluates constant expressions of the A-instruction.
+//   Format: @SCREEN+32*row, @0x4000, @0b1111, @'A', @(LOOP+2)|1
+// Operands are decimal, hexadecimal (0x) and binary (0b) numbers, character
+// literals and symbols. Operators from the lowest precedence are |, &, + and -, * and /.
+// Unary minus is allowed, all operators are left-associative.
+type expression struct {
+	input   string
+	tokens  []token
+	next    int
+	resolve func(symbol string) (int, bool)
+}
+
+// evaluate returns the value of the input expression. The resolve returns
+// the value of the symbol, or false if the symbol is undefined.
+func evaluate(input string, resolve func(symbol string) (int, bool)) (int, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return 0, fmt.Errorf("%v in expression %q", err, input)
+	}
+
+	e := &expression{input: input, tokens: tokens, resolve: resolve}
+
+	value, err := e.parseOr()
+	if err != nil {
+		return 0, err
+	}
+
+	if e.next < len(e.tokens) {
+		return 0, fmt.Errorf("unexpected %q in expression %q", input[e.tokens[e.next].column-1:], input)
+	}
+
+	return value, nil
+}
+
+// accept moves after the operator if the operator is the next token.
+func (e *expression) accept(operator string) bool {
+	if e.next < len(e.tokens) && e.tokens[e.next].is(operator) {
+		e.next++
+		return true
+	}
+
+	return false
+}
+
+// parseBinary parses operands separated by the operators.
+func (e *expression) parseBinary(operators string, operand func() (int, error)) (int, error) {
+	value, err := operand()
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		if e.next == len(e.tokens) || e.tokens[e.next].kind != operator || !strings.Contains(operators, e.tokens[e.next].text) {
+			return value, nil
+		}
+
+		operator := e.tokens[e.next].text
+		e.next++
+
+		right, err := operand()
+		if err != nil {
+			return 0, err
+		}
+
+		switch operator {
+		case "|":
+			value |= right
+		case "&":
+			value &= right
+		case "+":
+			value += right
+		case "-":
+			value -= right
+		case "*":
+			value *= right
+		case "/":
+			if right == 0 {
+				return 0, fmt.Errorf("division by zero in expression %q", e.input)
+			}
+			value /= right
+		}
+	}
+}
+
+// parseOr parses the operator with the lowest precedence.
+func (e *expression) parseOr() (int, error) { return e.parseBinary("|", e.parseAnd) }
+
+func (e *expression) parseAnd() (int, error) { return e.parseBinary("&", e.parseSum) }
+
+func (e *expression) parseSum() (int, error) { return e.parseBinary("+-", e.parseProduct) }
+
+func (e *expression) parseProduct() (int, error) { return e.parseBinary("*/", e.parseUnary) }
+
+// parseUnary parses the operand optionally preceded by the unary minus.
+func (e *expression) parseUnary() (int, error) {
+	if e.accept("-") {
+		value, err := e.parseUnary()
+		return -value, err
+	}
+
+	return e.parseOperand()
+}
+
+// parseOperand parses a number, a character, a symbol or an expression in parentheses.
+func (e *expression) parseOperand() (int, error) {
+	if e.accept("(") {
+		value, err := e.parseOr()
+		if err != nil {
+			return 0, err
+		}
+
+		if !e.accept(")") {
+			return 0, fmt.Errorf("missing ) in expression %q", e.input)
+		}
+
+		return value, nil
+	}
+
+	if e.next == len(e.tokens) {
+		return 0, fmt.Errorf("missing operand in expression %q", e.input)
+	}
+
+	t := e.tokens[e.next]
+	e.next++
+
+	switch t.kind {
+	case character:
+		return int(t.text[1]), nil
+
+	case number:
+		return parseNumber(t.text)
+
+	case identifier:
+		value, ok := e.resolve(t.text)
+		if !ok {
+			return 0, fmt.Errorf("undefined symbol %q in expression %q", t.text, e.input)
+		}
+
+		return value, nil
+
+	default:
+		return 0, fmt.Errorf("unexpected %q in expression %q", t.text, e.input)
+	}
+}
+
+// parseNumber parses the decimal, hexadecimal (0x) or binary (0b) number.
+func parseNumber(number string) (int, error) {
+	base, digits := 10, number
+
+	switch {
+	case strings.HasPrefix(number, "0x") || strings.HasPrefix(number, "0X"):
+		base, digits = 16, number[2:]
+	case strings.HasPrefix(number, "0b") || strings.HasPrefix(number, "0B"):
+		base, digits = 2, number[2:]
+	}
+
+	value, err := strconv.ParseUint(digits, base, 32)
+	if numError, ok := err.(*strconv.NumError); ok && numError.Err == strconv.ErrRange {
+		return 0, fmt.Errorf("constant %s exceeds %d", number, maxConstant)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invalid constant %q", number)
+	}
+
+	return int(value), nil
+}
@@ -0,0 +1,82 @@
+package asm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	symbols := map[string]int{"SCREEN": 0x4000, "row": 3, "LOOP": 10}
+	resolve := func(symbol string) (int, bool) {
+		value, ok := symbols[symbol]
+		return value, ok
+	}
+
+	tests := []struct {
+		input string
+		value int
+	}{
+		{"42", 42},
+		{"0x4000", 0x4000},
+		{"0B1111", 15},
+		{"'A'", 65},
+		{"SCREEN+32*row", 0x4000 + 96},
+		{"(LOOP+2)|1", 13},
+		{"1+2*3", 7},
+		{"(1+2)*3", 9},
+		{"10-4-3", 3},
+		{"100/10/5", 2},
+		{"6&3|8", 10},
+		{"-1+3", 2},
+		{"--5", 5},
+		{"SCREEN-1", 0x3FFF},
+	}
+
+	for _, test := range tests {
+		value, err := evaluate(test.input, resolve)
+		if err != nil {
+			t.Errorf("evaluate(%q): %v", test.input, err)
+			continue
+		}
+
+		if value != test.value {
+			t.Errorf("evaluate(%q) = %d, expected %d", test.input, value, test.value)
+		}
+	}
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"UNKNOWN+1", `undefined symbol "UNKNOWN"`},
+		{"(1+2", "missing )"},
+		{"1+", "missing operand"},
+		{"1 2", `unexpected "2"`},
+		{"0x", `invalid constant "0x"`},
+		{"99999999999", "exceeds"},
+		{"1#2", "unexpected character"},
+	}
+
+	for _, test := range tests {
+		_, err := evaluate(test.input, func(string) (int, bool) { return 0, false })
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("evaluate(%q) error %v, expected %q", test.input, err, test.err)
+		}
+	}
+}
+
+func TestAssembleExpressions(t *testing.T) {
+	program, err := AssembleString(".equ WIDTH 32\n(START)\n@SCREEN+WIDTH*2\nD = M + 1\n@START+1\n0 ; JMP\n", "test.asm", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []uint16{0x4040, 0xFDD0, 1, 0xEA87}
+	for i, word := range expected {
+		if program.Words[i] != word {
+			t.Errorf("ROM[%d] = %016b, expected %016b", i, program.Words[i], word)
+		}
+	}
+}
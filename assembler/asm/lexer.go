@@ -0,0 +1,104 @@
+package asm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tokenKind represents the kind of the token
+type tokenKind int
+
+const (
+	// identifier is a symbol or a mnemonic, e.g. LOOP, R0, AM, JGT
+	identifier tokenKind = iota
+	// number is a decimal, hexadecimal (0x) or binary (0b) number
+	number
+	// character is a character literal, e.g. 'A'
+	character
+	// operator is a single character operator or punctuation, e.g. @ ( ) = ; + -
+	operator
+)
+
+// operators contains the characters which are lexed as operators
+const operators = "@()=;+-*/&|!,%"
+
+// token represents a lexical unit of the line
+type token struct {
+	kind tokenKind
+	text string
+	// column is the 1-based byte offset of the token in the line
+	column int
+}
+
+// is checks if the token is the given operator
+func (t token) is(op string) bool { return t.kind == operator && t.text == op }
+
+// lexError represents an invalid character at the column of the line
+type lexError struct {
+	column  int
+	message string
+}
+
+func (e *lexError) Error() string { return e.message }
+
+// lex splits the line into tokens. Whitespaces separate tokens and are otherwise
+// ignored, // starts a comment anywhere outside of a character literal.
+func lex(line string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(line); {
+		char := line[i]
+		start := i
+
+		switch {
+		case char == ' ' || char == '\t' || char == '\r':
+			i++
+			continue
+		case strings.HasPrefix(line[i:], "//"):
+			return tokens, nil
+		case char == '\'':
+			if len(line) < i+3 || line[i+2] != '\'' {
+				return tokens, &lexError{i + 1, "invalid character literal"}
+			}
+
+			tokens = append(tokens, token{character, line[i : i+3], i + 1})
+			i += 3
+			continue
+		case isDigit(char) || isSymbolCharacter(char):
+			for i < len(line) && (isDigit(line[i]) || isSymbolCharacter(line[i])) {
+				i++
+			}
+
+			kind := identifier
+			if isDigit(char) {
+				kind = number
+			}
+
+			tokens = append(tokens, token{kind, line[start:i], start + 1})
+			continue
+		case strings.IndexByte(operators, char) != -1:
+			tokens = append(tokens, token{operator, string(char), i + 1})
+			i++
+			continue
+		}
+
+		return tokens, &lexError{i + 1, fmt.Sprintf("unexpected character %q", char)}
+	}
+
+	return tokens, nil
+}
+
+// stripComment returns the line without the comment, // inside of a character literal
+// doesn't start the comment.
+func stripComment(line string) string {
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\'' && i+2 < len(line) && line[i+2] == '\'':
+			i += 2
+		case strings.HasPrefix(line[i:], "//"):
+			return line[:i]
+		}
+	}
+
+	return line
+}
@@ -0,0 +1,67 @@
+package asm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		line   string
+		tokens []token
+	}{
+		{"@LOOP", []token{{operator, "@", 1}, {identifier, "LOOP", 2}}},
+		{"  AM = M+1 ; JGT // comment", []token{
+			{identifier, "AM", 3}, {operator, "=", 6}, {identifier, "M", 8}, {operator, "+", 9},
+			{number, "1", 10}, {operator, ";", 12}, {identifier, "JGT", 14},
+		}},
+		{"@'/'+0x10", []token{{operator, "@", 1}, {character, "'/'", 2}, {operator, "+", 5}, {number, "0x10", 6}}},
+		{"(Main.main$if.1)", []token{{operator, "(", 1}, {identifier, "Main.main$if.1", 2}, {operator, ")", 16}}},
+		{"", nil},
+	}
+
+	for _, test := range tests {
+		tokens, err := lex(test.line)
+		if err != nil {
+			t.Errorf("lex(%q): %v", test.line, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(tokens, test.tokens) {
+			t.Errorf("lex(%q) = %v, expected %v", test.line, tokens, test.tokens)
+		}
+	}
+}
+
+func TestLexErrors(t *testing.T) {
+	tests := []struct {
+		line   string
+		column int
+	}{
+		{"D=M#1", 4},
+		{"@'AB'", 2},
+		{"@'", 2},
+	}
+
+	for _, test := range tests {
+		_, err := lex(test.line)
+		if e, ok := err.(*lexError); !ok || e.column != test.column {
+			t.Errorf("lex(%q) error %v, expected the error at column %d", test.line, err, test.column)
+		}
+	}
+}
+
+func TestStripComment(t *testing.T) {
+	tests := map[string]string{
+		"D=M // comment": "D=M ",
+		"@'/' // slash":  "@'/' ",
+		"// comment":     "",
+		"0;JMP":          "0;JMP",
+	}
+
+	for line, expected := range tests {
+		if stripped := stripComment(line); stripped != expected {
+			t.Errorf("stripComment(%q) = %q, expected %q", line, stripped, expected)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package asm
+
+import "fmt"
+
+// linkedVariable is the variable allocated by the linker with its module
+type linkedVariable struct {
+	module  string
+	name    string
+	address uint16
+}
+
+// Link lays out the objects into the ROM in the given order, so the first
+// object starts at the address 0, and resolves the imported labels.
+// Variables of all modules are allocated in the RAM from the address 16,
+// skipping the fixed variables. The check configures how the variables outside
+// of the static region are reported. Undefined and duplicate symbols, collisions
+// of the fixed variables, allocated variables named like the label exported by another
+// module and the ROM overflow are returned at once as the ErrorList.
+//
+// The symbol table of the program contains exported labels by their names,
+// other labels and variables are prefixed by the module, e.g. Main:LOOP.
+func Link(objects []*Object, check RAMCheck) (*Program, error) {
+	var errors ErrorList
+	errorf := func(module, format string, args ...interface{}) {
+		errors = append(errors, &Error{Position{Filename: module}, fmt.Sprintf(format, args...)})
+	}
+
+	program := &Program{Symbols: newSymbolTable()}
+
+	// Layout of the ROM
+	bases := make([]int, len(objects))
+	modules := map[string]bool{}
+	size := 0
+	for i, object := range objects {
+		if modules[object.Module] {
+			errorf(object.Module, "duplicate module %s", object.Module)
+		}
+		modules[object.Module] = true
+
+		bases[i] = size
+		size += len(object.Words)
+	}
+
+	// Exported labels
+	exports := map[string]string{}
+	for i, object := range objects {
+		for name, address := range object.Labels {
+			program.Symbols[object.Module+":"+name] = Symbol{uint16(bases[i]) + address, Label}
+		}
+
+		for _, name := range object.Exports {
+			address, ok := object.Labels[name]
+			switch {
+			case !ok:
+				errorf(object.Module, "exported symbol %q isn't a label", name)
+			case exports[name] != "":
+				errorf(object.Module, "duplicate symbol %q, previously exported by %s", name, exports[name])
+			case program.Symbols.contains(name):
+				errorf(object.Module, "exported symbol %q redefines a predefined symbol", name)
+			default:
+				exports[name] = object.Module
+				program.Symbols[name] = Symbol{uint16(bases[i]) + address, Label}
+			}
+		}
+	}
+
+	// Fixed variables are placed first, so the allocated ones can skip them
+	ram := newRAMAllocator()
+	owners := map[uint16]linkedVariable{}
+	variables := map[string]uint16{}
+
+	for _, object := range objects {
+		for _, variable := range object.Variables {
+			if !variable.Fixed {
+				continue
+			}
+
+			if owner, ok := owners[variable.Address]; ok {
+				errorf(object.Module, "RAM address %d of variable %s collides with variable %s of %s",
+					variable.Address, variable.Name, owner.name, owner.module)
+				continue
+			}
+
+			owners[variable.Address] = linkedVariable{object.Module, variable.Name, variable.Address}
+			ram.place(Position{Filename: object.Module}, object.Module+":"+variable.Name, variable.Address)
+			variables[object.Module+":"+variable.Name] = variable.Address
+		}
+	}
+
+	for _, object := range objects {
+		for _, variable := range object.Variables {
+			// The variable is usually declared implicitly by @NAME without the .import of the label
+			if module := exports[variable.Name]; !variable.Fixed && module != "" && module != object.Module {
+				errorf(object.Module, "variable %s has the name of the label exported by %s, missing .import %s?",
+					variable.Name, module, variable.Name)
+			}
+
+			if !variable.Fixed {
+				name := object.Module + ":" + variable.Name
+				variables[name] = ram.allocate(Position{Filename: object.Module}, name, variable.Size)
+			}
+		}
+	}
+
+	for name, address := range variables {
+		program.Symbols[name] = Symbol{address, Variable}
+	}
+
+	// Imported labels have to be exported by another module
+	for _, object := range objects {
+		imported := map[string]bool{}
+		for _, name := range object.Imports {
+			imported[name] = true
+			if exports[name] == "" {
+				errorf(object.Module, "undefined symbol %q imported by %s", name, object.Module)
+			}
+		}
+
+		if word, ok := undeclaredImport(object, imported); ok {
+			errorf(object.Module, "symbol %q isn't declared by the .import directive", word.Symbol)
+		}
+	}
+
+	// Relocation of the words
+	for i, object := range objects {
+		for address, word := range object.Words {
+			value, ok := int(word.Word), true
+
+			switch word.Relocation {
+			case RelocateLabel:
+				value += bases[i]
+			case RelocateVariable:
+				var variable uint16
+				variable, ok = variables[object.Module+":"+word.Symbol]
+				value = int(variable)
+			case RelocateImport:
+				// Undefined imports are already reported
+				value = int(program.Symbols[word.Symbol].Address)
+			}
+
+			switch {
+			case !ok:
+				errorf(object.Module, "undefined symbol %q at address %d", word.Symbol, address)
+			case word.Relocation != Absolute && value > maxConstant:
+				errorf(object.Module, "address %d of %q exceeds %d", value, word.Symbol, maxConstant)
+			}
+
+			program.Words = append(program.Words, uint16(value))
+		}
+	}
+
+	if len(objects) > 0 {
+		if err := checkROM(objects[0].Module, size); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	warnings, ramErrors := checkRAM(ram.blocks, check)
+	errors = append(errors, ramErrors...)
+	program.Warnings, program.variables = warnings, ram.blocks
+
+	if err := errors.err(); err != nil {
+		return nil, err
+	}
+
+	return program, nil
+}
+
+// undeclaredImport returns the first word referring to the import which isn't in the imported
+func undeclaredImport(object *Object, imported map[string]bool) (ObjectWord, bool) {
+	for _, word := range object.Words {
+		if word.Relocation == RelocateImport && !imported[word.Symbol] {
+			return word, true
+		}
+	}
+
+	return ObjectWord{}, false
+}
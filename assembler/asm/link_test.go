@@ -0,0 +1,147 @@
+package asm
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	mainModule = `.import Double
+.export Back
+@21
+D=A
+@x
+M=D
+@Double
+0;JMP
+(Back)
+@Back
+0;JMP
+`
+	libModule = `.import Back
+.export Double
+(Double)
+@y
+M=D
+D=D+M
+@Back
+0;JMP
+`
+)
+
+// assembleObjects assembles the modules and reads them back from their object files
+func assembleObjects(t *testing.T, modules map[string]string, order ...string) []*Object {
+	t.Helper()
+
+	var objects []*Object
+	for _, filename := range order {
+		object, err := AssembleObject(strings.NewReader(modules[filename]), filename, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var file bytes.Buffer
+		if err := object.WriteObject(&file); err != nil {
+			t.Fatal(err)
+		}
+
+		read, err := ReadObject(&file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(read, object) {
+			t.Errorf("object of %s read as %+v, expected %+v", filename, read, object)
+		}
+
+		objects = append(objects, read)
+	}
+
+	return objects
+}
+
+func TestLinkRoundTrip(t *testing.T) {
+	objects := assembleObjects(t, map[string]string{"Main.asm": mainModule, "Lib.asm": libModule}, "Main.asm", "Lib.asm")
+
+	linked, err := Link(objects, RAMWarn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The single program has the same layout without the directives
+	var source strings.Builder
+	for _, line := range strings.Split(mainModule+libModule, "\n") {
+		if !strings.HasPrefix(line, ".") {
+			source.WriteString(line + "\n")
+		}
+	}
+
+	program, err := AssembleString(source.String(), "Main.asm", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(linked.Words, program.Words) {
+		t.Errorf("linked %v, expected %v", linked.Words, program.Words)
+	}
+
+	expected := map[string]Symbol{
+		"Double":     {8, Label},
+		"Back":       {6, Label},
+		"Lib:Double": {8, Label},
+		"Main:Back":  {6, Label},
+		"Main:x":     {16, Variable},
+		"Lib:y":      {17, Variable},
+		"SP":         {0, Predefined},
+	}
+	for name, symbol := range expected {
+		if linked.Symbols[name] != symbol {
+			t.Errorf("symbol %s is %+v, expected %+v", name, linked.Symbols[name], symbol)
+		}
+	}
+}
+
+func TestLinkErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		modules map[string]string
+		order   []string
+		err     string
+	}{
+		{
+			name:    "undefined import",
+			modules: map[string]string{"Main.asm": mainModule},
+			order:   []string{"Main.asm"},
+			err:     `Main: undefined symbol "Double" imported by Main`,
+		},
+		{
+			name:    "duplicate export",
+			modules: map[string]string{"Main.asm": mainModule, "Lib.asm": libModule, "Copy.asm": libModule},
+			order:   []string{"Main.asm", "Lib.asm", "Copy.asm"},
+			err:     `Copy: duplicate symbol "Double", previously exported by Lib`,
+		},
+		{
+			name:    "missing import",
+			modules: map[string]string{"Main.asm": ".export Back\n@Double\n0;JMP\n(Back)\n", "Lib.asm": libModule},
+			order:   []string{"Main.asm", "Lib.asm"},
+			err:     "Main: variable Double has the name of the label exported by Lib, missing .import Double?",
+		},
+		{
+			name:    "collision of fixed variables",
+			modules: map[string]string{"A.asm": ".var a 100\n@a\n", "B.asm": ".var b 100\n@b\n"},
+			order:   []string{"A.asm", "B.asm"},
+			err:     "B: RAM address 100 of variable b collides with variable a of A",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Link(assembleObjects(t, test.modules, test.order...), RAMWarn)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("got error %v, expected %q", err, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package asm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WriteListing writes the listing, one line per instruction or label with tab separated
+// ROM address, binary code ("-" for labels), filename, line number and source line.
+func (p *Program) WriteListing(w io.Writer) error {
+	var builder strings.Builder
+
+	for _, line := range p.Listing {
+		binary := "-"
+		if line.Instruction {
+			binary = fmt.Sprintf("%016b", line.Word)
+		}
+
+		fmt.Fprintf(&builder, "%d\t%s\t%s\t%d\t%s\n", line.Address, binary, line.Pos.Filename, line.Pos.Line, line.Text)
+	}
+
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
+
+// ReadListing reads the listing written by WriteListing. Positions of the lines have only the filename and the line.
+func ReadListing(input io.Reader) ([]ListingLine, error) {
+	var listing []ListingLine
+
+	err := readTabSeparated(input, 5, func(fields []string) error {
+		address, err := strconv.ParseUint(fields[0], 10, 16)
+		if err != nil {
+			return fmt.Errorf("invalid address %q", fields[0])
+		}
+
+		line := ListingLine{Address: uint16(address), Pos: Position{Filename: fields[2]}, Text: fields[4]}
+
+		if fields[1] != "-" {
+			word, err := strconv.ParseUint(fields[1], 2, 16)
+			if err != nil {
+				return fmt.Errorf("invalid binary code %q", fields[1])
+			}
+
+			line.Instruction, line.Word = true, uint16(word)
+		}
+
+		if line.Pos.Line, err = strconv.Atoi(fields[3]); err != nil {
+			return fmt.Errorf("invalid line number %q", fields[3])
+		}
+
+		listing = append(listing, line)
+		return nil
+	})
+
+	return listing, err
+}
+
+// WriteSymbols writes the symbol table, one line per symbol with tab separated
+// name, address and kind. The symbols are sorted by their kind and address.
+func (t SymbolTable) WriteSymbols(w io.Writer) error {
+	symbols := make([]string, 0, len(t))
+	for symbol := range t {
+		symbols = append(symbols, symbol)
+	}
+
+	sort.Slice(symbols, func(i, j int) bool {
+		a, b := t[symbols[i]], t[symbols[j]]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Address != b.Address {
+			return a.Address < b.Address
+		}
+		return symbols[i] < symbols[j]
+	})
+
+	var builder strings.Builder
+	for _, symbol := range symbols {
+		fmt.Fprintf(&builder, "%s\t%d\t%s\n", symbol, t[symbol].Address, t[symbol].Kind)
+	}
+
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
+
+// ReadSymbols reads the symbol table written by WriteSymbols.
+func ReadSymbols(input io.Reader) (SymbolTable, error) {
+	table := SymbolTable{}
+
+	err := readTabSeparated(input, 3, func(fields []string) error {
+		address, err := strconv.ParseUint(fields[1], 10, 16)
+		if err != nil {
+			return fmt.Errorf("invalid address %q", fields[1])
+		}
+
+		for kind, name := range symbolKindNames {
+			if name == fields[2] {
+				table[fields[0]] = Symbol{uint16(address), kind}
+				return nil
+			}
+		}
+
+		return fmt.Errorf("unknown symbol kind %q", fields[2])
+	})
+
+	return table, err
+}
+
+// readTabSeparated calls the handle for every line of the input
+// with the given number of tab separated fields.
+func readTabSeparated(input io.Reader, fields int, handle func(fields []string) error) error {
+	scanner := bufio.NewScanner(input)
+
+	for line := 1; scanner.Scan(); line++ {
+		parts := strings.SplitN(scanner.Text(), "\t", fields)
+		if len(parts) != fields {
+			return fmt.Errorf("line %d: expected %d tab separated fields", line, fields)
+		}
+
+		if err := handle(parts); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+
+	return scanner.Err()
+}
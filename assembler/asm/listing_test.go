@@ -0,0 +1,80 @@
+package asm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestListingRoundTrip(t *testing.T) {
+	// The filename with colons can't be split at the last colon of file:line
+	const filename = "C:/games/Pong:v2.asm"
+
+	program, err := AssembleString(".equ ROWS 16\n(LOOP)\n@ROWS\nD=A // rows\n@i\nM=D\n@LOOP\n0;JMP\n", filename, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var listing strings.Builder
+	if err := program.WriteListing(&listing); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := ReadListing(strings.NewReader(listing.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := make([]ListingLine, 0, len(program.Listing))
+	for _, line := range program.Listing {
+		line.Pos = Position{Filename: line.Pos.Filename, Line: line.Pos.Line}
+		expected = append(expected, line)
+	}
+
+	if !reflect.DeepEqual(read, expected) {
+		t.Errorf("read %+v, expected %+v", read, expected)
+	}
+
+	if read[1].Pos.Filename != filename || read[1].Pos.Line != 2 {
+		t.Errorf("position %s, expected %s:2", read[1].Pos, filename)
+	}
+}
+
+func TestSymbolsRoundTrip(t *testing.T) {
+	program, err := AssembleString(".equ ROWS 16\n(LOOP)\n@ROWS\n@i\n@LOOP\n", "Main.asm", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var symbols strings.Builder
+	if err := program.Symbols.WriteSymbols(&symbols); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := ReadSymbols(strings.NewReader(symbols.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(read, program.Symbols) {
+		t.Errorf("read %v, expected %v", read, program.Symbols)
+	}
+}
+
+func TestReadListingErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"0\t-\tMain.asm\t1\n", "line 1: expected 5 tab separated fields"},
+		{"0\t-\tMain.asm:1\t(LOOP)\n", "line 1: expected 5 tab separated fields"},
+		{"0\t-\tMain.asm\tx\t(LOOP)\n", `line 1: invalid line number "x"`},
+		{"0\t2\tMain.asm\t1\t@2\n", `line 1: invalid binary code "2"`},
+	}
+
+	for _, test := range tests {
+		if _, err := ReadListing(strings.NewReader(test.input)); err == nil || err.Error() != test.err {
+			t.Errorf("ReadListing(%q) error %v, expected %q", test.input, err, test.err)
+		}
+	}
+}
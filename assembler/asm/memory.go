@@ -0,0 +1,204 @@
+package asm
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ROMSize is the number of instructions which fit into the ROM
+const ROMSize = 0x8000
+
+// lastRAMAddress is the address of the keyboard, the last word of the data memory
+const lastRAMAddress = 0x6000
+
+// ramRegion is the region of the RAM used by the VM code or the memory-mapped I/O
+type ramRegion struct {
+	name       string
+	start, end uint16
+}
+
+// staticRegion is the region of the RAM for variables and static variables of the VM code
+var staticRegion = ramRegion{"static", firstRAMAddress, 255}
+
+// ramRegions contains the regions following the static region, which variables shouldn't reach
+var ramRegions = []ramRegion{
+	{"stack", 256, 2047},
+	{"heap", 2048, 0x3FFF},
+	{"screen", 0x4000, 0x5FFF},
+	{"keyboard", 0x6000, 0x6000},
+}
+
+// RAMCheck configures how variables allocated outside of the static region 16-255 are reported.
+type RAMCheck int
+
+const (
+	// RAMWarn reports the variables as warnings of the program
+	RAMWarn RAMCheck = iota
+	// RAMError reports the variables as errors
+	RAMError
+	// RAMIgnore doesn't report the variables
+	RAMIgnore
+)
+
+var ramCheckNames = map[RAMCheck]string{
+	RAMWarn:   "warn",
+	RAMError:  "error",
+	RAMIgnore: "ignore",
+}
+
+func (c RAMCheck) String() string { return ramCheckNames[c] }
+
+// ParseRAMCheck returns the RAM check by its name: warn, error or ignore.
+func ParseRAMCheck(name string) (RAMCheck, error) {
+	for check, checkName := range ramCheckNames {
+		if checkName == name {
+			return check, nil
+		}
+	}
+
+	return RAMWarn, fmt.Errorf("unknown RAM check %q, expected warn, error or ignore", name)
+}
+
+// ramBlock is the RAM block of the variable declared at the position
+type ramBlock struct {
+	pos     Position
+	name    string
+	address uint16
+	size    int
+	// fixed is true for variables placed explicitly by the .var directive
+	fixed bool
+}
+
+// checkRAM reports the allocated variables reaching outside of the static region.
+// Variables reaching past the keyboard, the last word of the data memory, are always errors.
+func checkRAM(blocks []ramBlock, check RAMCheck) (warnings, errors ErrorList) {
+	for _, block := range blocks {
+		end := int(block.address) + block.size - 1
+		if end > lastRAMAddress {
+			errors = append(errors, &Error{block.pos, fmt.Sprintf("variable %s of %d words doesn't fit into the RAM", block.name, block.size)})
+			continue
+		}
+
+		if block.fixed || check == RAMIgnore {
+			continue
+		}
+
+		for _, region := range ramRegions {
+			if end < int(region.start) || int(block.address) > int(region.end) {
+				continue
+			}
+
+			err := &Error{block.pos, fmt.Sprintf("variable %s at RAM[%d] collides with the %s (%d-%d)",
+				block.name, block.address, region.name, region.start, region.end)}
+			if check == RAMError {
+				errors = append(errors, err)
+			} else {
+				warnings = append(warnings, err)
+			}
+			break
+		}
+	}
+
+	return warnings, errors
+}
+
+// checkROM reports the program which doesn't fit into the ROM
+func checkROM(filename string, size int) *Error {
+	if size <= ROMSize {
+		return nil
+	}
+
+	return &Error{Position{Filename: filename}, fmt.Sprintf("program has %d instructions, exceeds the ROM of %d words", size, ROMSize)}
+}
+
+// staticVariable matches static variables of the VM code, e.g. Main.3
+var staticVariable = regexp.MustCompile(`^[^.]+\.[0-9]+$`)
+
+// Usage summarizes the memory used by the program.
+type Usage struct {
+	// ROM is the number of instructions
+	ROM int
+	// Static is the number of words of the static variables of the VM code named File.index
+	Static int
+	// Variables is the number of words of the other variables
+	Variables int
+	// Functions contains the ROM used by the functions of the VM code ordered by addresses.
+	// Functions are the labels containing '.' and no '$', e.g. Main.main.
+	Functions []FunctionUsage
+}
+
+// FunctionUsage represents the instructions of the function starting at the Address.
+type FunctionUsage struct {
+	Name    string
+	Address uint16
+	ROM     int
+}
+
+// Usage returns the summary of the memory used by the program.
+func (p *Program) Usage() Usage {
+	usage := Usage{ROM: len(p.Words)}
+
+	for _, block := range p.variables {
+		name := block.name[strings.LastIndex(block.name, ":")+1:]
+		if staticVariable.MatchString(name) {
+			usage.Static += block.size
+		} else {
+			usage.Variables += block.size
+		}
+	}
+
+	for name, symbol := range p.Symbols {
+		function := name[strings.LastIndex(name, ":")+1:]
+
+		// Exported labels of linked programs are also in the table without the module
+		if exported, ok := p.Symbols[function]; ok && function != name && exported == symbol {
+			continue
+		}
+
+		if symbol.Kind == Label && strings.Contains(function, ".") && !strings.Contains(function, "$") {
+			usage.Functions = append(usage.Functions, FunctionUsage{Name: name, Address: symbol.Address})
+		}
+	}
+
+	sort.Slice(usage.Functions, func(i, j int) bool {
+		a, b := usage.Functions[i], usage.Functions[j]
+		if a.Address != b.Address {
+			return a.Address < b.Address
+		}
+		return a.Name < b.Name
+	})
+
+	for i := range usage.Functions {
+		end := usage.ROM
+		if i+1 < len(usage.Functions) {
+			end = int(usage.Functions[i+1].Address)
+		}
+		usage.Functions[i].ROM = end - int(usage.Functions[i].Address)
+	}
+
+	return usage
+}
+
+// WriteUsage writes the summary, followed by the tab separated address, size and name
+// of every function
+func (u Usage) WriteUsage(w io.Writer) error {
+	var builder strings.Builder
+
+	staticSize := int(staticRegion.end-staticRegion.start) + 1
+	fmt.Fprintf(&builder, "ROM: %d of %d words (%.1f%%)\n", u.ROM, ROMSize, 100*float64(u.ROM)/ROMSize)
+	fmt.Fprintf(&builder, "static: %d of %d words\n", u.Static, staticSize)
+	fmt.Fprintf(&builder, "variables: %d words\n", u.Variables)
+
+	if len(u.Functions) > 0 {
+		fmt.Fprintf(&builder, "functions:\n")
+	}
+	for _, function := range u.Functions {
+		fmt.Fprintf(&builder, "%d\t%d\t%s\n", function.Address, function.ROM, function.Name)
+	}
+
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
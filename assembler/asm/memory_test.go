@@ -0,0 +1,59 @@
+package asm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestROMOverflow(t *testing.T) {
+	full := strings.Repeat("D=0\n", ROMSize)
+
+	program, err := AssembleString("(START)\n"+full, "test.asm", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(program.Words) != ROMSize {
+		t.Errorf("%d words, expected %d", len(program.Words), ROMSize)
+	}
+
+	// @END would load 0x8000, which is the C-instruction
+	_, err = AssembleString(full+"(END)\n@END\n", "test.asm", Options{})
+	expected := "test.asm:32769: label END at address 32768 is outside of the ROM of 32768 words"
+	if err == nil || !strings.HasPrefix(err.Error(), expected) {
+		t.Errorf("got error %v, expected %s", err, expected)
+	}
+
+	// Labels after the overflow would wrap around to 0 with 16-bit addresses
+	_, err = AssembleString(full+strings.Repeat("D=0\n", ROMSize)+"(WRAP)\n@WRAP\n", "test.asm", Options{})
+	expected = "test.asm:32769: instruction doesn't fit into the ROM of 32768 words"
+	if err == nil || err.Error() != expected {
+		t.Errorf("got error %v, expected %s", err, expected)
+	}
+}
+
+func TestRAMLimit(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		err    string
+	}{
+		{"keyboard", ".var key 0x6000\n@key\n", ""},
+		{"block ending at the keyboard", ".block memory 0x6000-15\n@memory\n", ""},
+		{"variable after the keyboard", ".var unused 0x6001\n@unused\n", "test.asm:1: variable unused of 1 words doesn't fit into the RAM"},
+		{"block after the keyboard", ".block memory 0x6000-14\n@memory\n", "test.asm:1: variable memory of 24562 words doesn't fit into the RAM"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := AssembleString(test.source, "test.asm", Options{RAM: RAMIgnore})
+
+			switch {
+			case test.err == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case test.err != "" && (err == nil || err.Error() != test.err):
+				t.Errorf("got error %v, expected %s", err, test.err)
+			}
+		})
+	}
+}
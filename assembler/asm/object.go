@@ -0,0 +1,276 @@
+package asm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Relocation represents how the linker computes the final value of the word
+type Relocation int
+
+const (
+	// Absolute word is used as is
+	Absolute Relocation = iota
+	// RelocateLabel word contains the address of the label relative to the start of the module
+	RelocateLabel
+	// RelocateVariable word is replaced by the RAM address of the module variable
+	RelocateVariable
+	// RelocateImport word is replaced by the address of the label exported by another module
+	RelocateImport
+)
+
+var relocationNames = map[Relocation]string{
+	Absolute:         "absolute",
+	RelocateLabel:    "label",
+	RelocateVariable: "variable",
+	RelocateImport:   "import",
+}
+
+func (r Relocation) String() string { return relocationNames[r] }
+
+// Object represents the relocatable machine code of a single module.
+// Labels are local to the module unless they are exported,
+// variables are always local to the module.
+type Object struct {
+	// Module is the name of the module, the base name of its .asm file
+	Module string
+	Words  []ObjectWord
+	// Labels contains all labels with addresses relative to the start of the module
+	Labels map[string]uint16
+	// Exports contains labels which can be imported by other modules
+	Exports []string
+	// Imports contains labels exported by other modules
+	Imports   []string
+	Variables []ObjectVariable
+}
+
+// ObjectWord represents the instruction of the module and its relocation.
+// The Symbol is the label, variable or import referred by the relocated word.
+type ObjectWord struct {
+	Word       uint16
+	Relocation Relocation
+	Symbol     string
+}
+
+// ObjectVariable represents the variable of the module allocated by the linker.
+// Fixed variables are placed at the Address declared by the .var directive.
+type ObjectVariable struct {
+	Name    string
+	Size    int
+	Fixed   bool
+	Address uint16
+}
+
+// objectModule collects the relocation information while assembling the module
+type objectModule struct {
+	// relocations contains the symbols whose addresses are known only after the linking
+	relocations map[string]Relocation
+	exports     map[string]Position
+	imports     []string
+	variables   []ObjectVariable
+	words       []ObjectWord
+}
+
+func newObjectModule() *objectModule {
+	return &objectModule{relocations: map[string]Relocation{}, exports: map[string]Position{}}
+}
+
+// checkExports records exported symbols which aren't labels of the module as errors
+func (p *parser) checkExports() {
+	names := make([]string, 0, len(p.object.exports))
+	for name := range p.object.exports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if p.object.relocations[name] != RelocateLabel {
+			p.errors = append(p.errors, &Error{p.object.exports[name], fmt.Sprintf("exported symbol %q isn't a label", name)})
+		}
+	}
+}
+
+// AssembleObject translates the assembly of the module read from the input into the object.
+// The .export and .import directives declare labels shared with other modules, see Link.
+func AssembleObject(input io.Reader, filename string, options Options) (*Object, error) {
+	module := newObjectModule()
+
+	program, err := assemble(input, filename, options, module)
+	if err != nil {
+		return nil, err
+	}
+
+	object := &Object{
+		Module:    strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename)),
+		Words:     module.words,
+		Labels:    map[string]uint16{},
+		Imports:   module.imports,
+		Variables: module.variables,
+	}
+
+	for name, symbol := range program.Symbols {
+		if symbol.Kind == Label && module.relocations[name] == RelocateLabel {
+			object.Labels[name] = symbol.Address
+		}
+	}
+
+	for name := range module.exports {
+		object.Exports = append(object.Exports, name)
+	}
+	sort.Strings(object.Exports)
+
+	return object, nil
+}
+
+// WriteObject writes the object in the text format, one tab separated record per line
+//   module  NAME
+//   label   NAME  ADDRESS
+//   export  NAME
+//   import  NAME
+//   variable  NAME  SIZE [ADDRESS]
+//   word    BINARY [RELOCATION SYMBOL]
+func (o *Object) WriteObject(w io.Writer) error {
+	output := bufio.NewWriter(w)
+
+	fmt.Fprintf(output, "module\t%s\n", o.Module)
+
+	labels := make([]string, 0, len(o.Labels))
+	for name := range o.Labels {
+		labels = append(labels, name)
+	}
+	sort.Strings(labels)
+
+	for _, name := range labels {
+		fmt.Fprintf(output, "label\t%s\t%d\n", name, o.Labels[name])
+	}
+	for _, name := range o.Exports {
+		fmt.Fprintf(output, "export\t%s\n", name)
+	}
+	for _, name := range o.Imports {
+		fmt.Fprintf(output, "import\t%s\n", name)
+	}
+
+	for _, variable := range o.Variables {
+		fmt.Fprintf(output, "variable\t%s\t%d", variable.Name, variable.Size)
+		if variable.Fixed {
+			fmt.Fprintf(output, "\t%d", variable.Address)
+		}
+		fmt.Fprintln(output)
+	}
+
+	for _, word := range o.Words {
+		fmt.Fprintf(output, "word\t%016b", word.Word)
+		if word.Relocation != Absolute {
+			fmt.Fprintf(output, "\t%s\t%s", word.Relocation, word.Symbol)
+		}
+		fmt.Fprintln(output)
+	}
+
+	return output.Flush()
+}
+
+// ReadObject reads the object written by the WriteObject.
+func ReadObject(input io.Reader) (*Object, error) {
+	object := &Object{Labels: map[string]uint16{}}
+
+	scanner := bufio.NewScanner(input)
+	for line := 1; scanner.Scan(); line++ {
+		if err := object.readRecord(strings.Split(scanner.Text(), "\t")); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if object.Module == "" {
+		return nil, fmt.Errorf("missing module record")
+	}
+
+	return object, nil
+}
+
+// readRecord adds the record given by its tab separated fields into the object
+func (o *Object) readRecord(fields []string) error {
+	expect := map[string][]int{
+		"module":   {2},
+		"label":    {3},
+		"export":   {2},
+		"import":   {2},
+		"variable": {3, 4},
+		"word":     {2, 4},
+	}
+
+	counts, ok := expect[fields[0]]
+	if !ok {
+		return fmt.Errorf("unknown record %q", fields[0])
+	}
+
+	if len(fields) != counts[0] && len(fields) != counts[len(counts)-1] {
+		return fmt.Errorf("invalid number of fields of %s record", fields[0])
+	}
+
+	switch fields[0] {
+	case "module":
+		o.Module = fields[1]
+
+	case "label":
+		address, err := strconv.ParseUint(fields[2], 10, 15)
+		if err != nil {
+			return fmt.Errorf("invalid address %q", fields[2])
+		}
+		o.Labels[fields[1]] = uint16(address)
+
+	case "export":
+		o.Exports = append(o.Exports, fields[1])
+
+	case "import":
+		o.Imports = append(o.Imports, fields[1])
+
+	case "variable":
+		size, err := strconv.Atoi(fields[2])
+		if err != nil || size <= 0 {
+			return fmt.Errorf("invalid size %q", fields[2])
+		}
+
+		variable := ObjectVariable{Name: fields[1], Size: size}
+		if len(fields) == 4 {
+			address, err := strconv.ParseUint(fields[3], 10, 15)
+			if err != nil {
+				return fmt.Errorf("invalid address %q", fields[3])
+			}
+			variable.Fixed, variable.Address = true, uint16(address)
+		}
+		o.Variables = append(o.Variables, variable)
+
+	case "word":
+		value, err := strconv.ParseUint(fields[1], 2, 16)
+		if err != nil || len(fields[1]) != 16 {
+			return fmt.Errorf("expected 16 binary digits, got %q", fields[1])
+		}
+
+		word := ObjectWord{Word: uint16(value)}
+		if len(fields) == 4 {
+			word.Symbol = fields[3]
+			word.Relocation = -1
+			for relocation, name := range relocationNames {
+				if name == fields[2] && relocation != Absolute {
+					word.Relocation = relocation
+				}
+			}
+
+			if word.Relocation == -1 {
+				return fmt.Errorf("unknown relocation %q", fields[2])
+			}
+		}
+		o.Words = append(o.Words, word)
+	}
+
+	return nil
+}
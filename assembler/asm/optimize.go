@@ -0,0 +1,402 @@
+package asm
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// OptimizeStats reports the instructions removed by the peephole optimizer.
+type OptimizeStats struct {
+	// Before and After are the numbers of instructions before and after the optimization
+	Before, After int
+	// Reloads is the number of removed @X which reloaded the value already in the A register
+	Reloads int
+	// PushPops is the number of removed instructions of push D followed by pop D
+	PushPops int
+	// Jumps is the number of removed instructions of jumps to the next instruction
+	Jumps int
+	// Labels is the number of removed unreferenced labels
+	Labels int
+}
+
+// Saved returns the number of removed instructions.
+func (s OptimizeStats) Saved() int { return s.Before - s.After }
+
+func (s OptimizeStats) String() string {
+	return fmt.Sprintf("%d -> %d instructions, saved %d (reloads %d, push/pop %d, jumps %d), removed %d labels",
+		s.Before, s.After, s.Saved(), s.Reloads, s.PushPops, s.Jumps, s.Labels)
+}
+
+// removal represents the reason why the optimizer removed the instruction
+type removal int
+
+const (
+	kept removal = iota
+	// removedNoop is an instruction without any observable effect, e.g. jump to the next instruction
+	removedNoop
+	// removedRedundant is an instruction whose effect is already done by the preceding instructions
+	removedRedundant
+)
+
+// instruction is a command of the preprocessed program examined by the optimizer
+type instruction struct {
+	line sourceLine
+	kind commandType
+	// command is the command without comments, A-commands are normalized to @symbol
+	// and C-commands to dest=comp;jump without spaces
+	command string
+	// symbol of the @symbol or (symbol)
+	symbol           string
+	dest, comp, jump string
+	removed          removal
+}
+
+// Instruction sequences of the push D and pop D, as written by the VM translator
+// and the pseudo-instructions
+var (
+	pushSequences = [][]string{
+		{"@SP", "A=M", "M=D", "@SP", "M=M+1"},
+		{"@SP", "M=M+1", "A=M", "A=A-1", "M=D"},
+		{"@SP", "AM=M+1", "A=A-1", "M=D"},
+	}
+	popSequences = [][]string{
+		{"@SP", "M=M-1", "A=M", "D=M"},
+		{"@SP", "AM=M-1", "D=M"},
+	}
+)
+
+// optimize runs the peephole optimizer on the preprocessed lines until no more instructions
+// can be removed. Blank lines and comments are dropped. Labels used in expressions are
+// reported as errors, because removed instructions would change the computed addresses.
+func optimize(lines []sourceLine) ([]sourceLine, OptimizeStats, ErrorList) {
+	p := newParser(lines, nil, false)
+
+	var program []*instruction
+	for p.hasMoreCommands() {
+		p.advance()
+
+		inst := &instruction{line: p.lines[p.next-1], kind: p.commandType(), command: p.command}
+		switch inst.kind {
+		case aCommand:
+			inst.symbol = p.symbol()
+			inst.command = "@" + inst.symbol
+		case lCommand:
+			inst.symbol, _ = p.label()
+		case cCommand:
+			// Malformed instructions are kept and reported by the translation
+			if c, ok := p.parseCCommand(); ok {
+				inst.dest, inst.comp, inst.jump = c.dest.text, c.comp.text, c.jump.text
+				inst.command = c.dest.text + "=" + c.comp.text + ";" + c.jump.text
+				inst.command = strings.TrimSuffix(strings.TrimPrefix(inst.command, "="), ";")
+			}
+		}
+
+		program = append(program, inst)
+	}
+
+	if errors := checkComputedLabels(program); len(errors) > 0 {
+		return lines, OptimizeStats{}, errors
+	}
+
+	var stats OptimizeStats
+	for _, inst := range program {
+		if inst.isInstruction() {
+			stats.Before++
+		}
+	}
+
+	for changed := true; changed; {
+		changed = removeUnreferencedLabels(program, &stats)
+		changed = removeJumpsToNext(program, &stats) || changed
+		changed = collapsePushPop(program, &stats) || changed
+		changed = removeReloads(program, &stats) || changed
+	}
+
+	if errors := checkLabelTargets(program); len(errors) > 0 {
+		return lines, OptimizeStats{}, errors
+	}
+
+	var optimized []sourceLine
+	for _, inst := range program {
+		if inst.removed == kept {
+			optimized = append(optimized, inst.line)
+		}
+	}
+
+	for _, inst := range program {
+		if inst.isInstruction() && inst.removed == kept {
+			stats.After++
+		}
+	}
+
+	return optimized, stats, nil
+}
+
+// isInstruction returns true for the A- and C-instructions, which occupy the ROM
+func (inst *instruction) isInstruction() bool { return inst.kind == aCommand || inst.kind == cCommand }
+
+// errorf returns the error at the line of the instruction
+func (inst *instruction) errorf(format string, args ...interface{}) *Error {
+	return &Error{inst.line.pos, fmt.Sprintf(format, args...)}
+}
+
+// live returns the instructions, labels and directives which weren't removed
+func live(program []*instruction) []*instruction {
+	var result []*instruction
+	for _, inst := range program {
+		if inst.removed == kept && inst.kind != directive {
+			result = append(result, inst)
+		}
+	}
+
+	return result
+}
+
+// identifiers returns the symbols used in the expression
+func identifiers(expression string) []string {
+	tokens, _ := lex(expression)
+
+	var result []string
+	for _, t := range tokens {
+		if t.kind == identifier {
+			result = append(result, t.text)
+		}
+	}
+
+	return result
+}
+
+// references returns the symbols used by the A-instructions and directives
+func references(program []*instruction) map[string]bool {
+	result := map[string]bool{}
+
+	for _, inst := range program {
+		if inst.removed != kept {
+			continue
+		}
+
+		switch inst.kind {
+		case aCommand:
+			for _, symbol := range identifiers(inst.symbol) {
+				result[symbol] = true
+			}
+		case directive:
+			for _, symbol := range identifiers(inst.command) {
+				result[symbol] = true
+			}
+		}
+	}
+
+	return result
+}
+
+// checkComputedLabels reports labels used in expressions and directives
+func checkComputedLabels(program []*instruction) ErrorList {
+	labels := map[string]bool{}
+	for _, inst := range program {
+		if inst.kind == lCommand {
+			labels[inst.symbol] = true
+		}
+	}
+
+	var errors ErrorList
+	for _, inst := range program {
+		expression := inst.symbol
+		if inst.kind == directive {
+			expression = inst.command
+		} else if inst.kind != aCommand || isValidSymbol(expression) {
+			continue
+		}
+
+		for _, symbol := range identifiers(expression) {
+			if labels[symbol] {
+				errors = append(errors, inst.errorf("can't optimize the address computed from the label %q", symbol))
+			}
+		}
+	}
+
+	return errors
+}
+
+// removeUnreferencedLabels removes labels which aren't used by any A-instruction or directive
+func removeUnreferencedLabels(program []*instruction, stats *OptimizeStats) bool {
+	referenced := references(program)
+	changed := false
+
+	for _, inst := range live(program) {
+		if inst.kind == lCommand && !referenced[inst.symbol] {
+			inst.removed = removedNoop
+			stats.Labels++
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+// removeJumpsToNext removes @LABEL with the following jump if the LABEL
+// is bound to the instruction right after the jump. The jump mustn't have any dest
+// and the LABEL has to be followed by an A-instruction, so the jump doesn't have any effect.
+func removeJumpsToNext(program []*instruction, stats *OptimizeStats) bool {
+	code := live(program)
+	changed := false
+
+	for i := 0; i+1 < len(code); i++ {
+		load, jump := code[i], code[i+1]
+		if load.kind != aCommand || jump.kind != cCommand || jump.dest != "" || jump.jump == "" {
+			continue
+		}
+
+		next := i + 2
+		target := false
+		for ; next < len(code) && code[next].kind == lCommand; next++ {
+			target = target || code[next].symbol == load.symbol
+		}
+
+		if target && (next == len(code) || code[next].kind == aCommand) {
+			load.removed, jump.removed = removedNoop, removedNoop
+			stats.Jumps += 2
+			changed = true
+			i++
+		}
+	}
+
+	return changed
+}
+
+// matchSequence returns the length of the sequence at the start of the code, or 0 if none matches
+func matchSequence(code []*instruction, sequences [][]string) int {
+	for _, sequence := range sequences {
+		if len(code) < len(sequence) {
+			continue
+		}
+
+		matches := true
+		for i, command := range sequence {
+			if code[i].kind == lCommand || code[i].command != command {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			return len(sequence)
+		}
+	}
+
+	return 0
+}
+
+// collapsePushPop removes push D immediately followed by pop D. The stack pointer and D
+// are unchanged by such sequence. The value of A differs, so the sequence has to be followed
+// by an A-instruction.
+func collapsePushPop(program []*instruction, stats *OptimizeStats) bool {
+	code := live(program)
+	changed := false
+
+	for i := 0; i < len(code); i++ {
+		push := matchSequence(code[i:], pushSequences)
+		if push == 0 {
+			continue
+		}
+
+		pop := matchSequence(code[i+push:], popSequences)
+		end := i + push + pop
+		if pop == 0 || end == len(code) || code[end].kind != aCommand {
+			continue
+		}
+
+		for _, inst := range code[i:end] {
+			inst.removed = removedNoop
+		}
+
+		stats.PushPops += push + pop
+		changed = true
+		i = end - 1
+	}
+
+	return changed
+}
+
+// removeReloads removes @X if the A register already contains the X. The content
+// of the A register is known from the last A-instruction until the next label
+// or a C-instruction with A in the dest.
+func removeReloads(program []*instruction, stats *OptimizeStats) bool {
+	known := ""
+	changed := false
+
+	for _, inst := range live(program) {
+		switch inst.kind {
+		case lCommand:
+			known = ""
+		case aCommand:
+			if inst.command == known {
+				inst.removed = removedRedundant
+				stats.Reloads++
+				changed = true
+			}
+			known = inst.command
+		case cCommand:
+			if strings.Contains(inst.dest, "A") {
+				known = ""
+			}
+		}
+	}
+
+	return changed
+}
+
+// checkLabelTargets verifies every kept label is still bound to the same instruction.
+// Only the instructions without any effect may be skipped in front of the original target.
+func checkLabelTargets(program []*instruction) ErrorList {
+	var errors ErrorList
+
+	for i, label := range program {
+		if label.kind != lCommand || label.removed != kept {
+			continue
+		}
+
+		for _, inst := range program[i+1:] {
+			if !inst.isInstruction() || inst.removed == removedNoop {
+				continue
+			}
+
+			if inst.removed != kept {
+				errors = append(errors, label.errorf("optimizer removed %q, the target of the label %q", inst.command, label.symbol))
+			}
+			break
+		}
+	}
+
+	return errors
+}
+
+// Optimize runs the peephole optimizer on the assembly read from the input
+// and writes the optimized assembly into the output. Macros, includes
+// and pseudo-instructions are expanded, comments are dropped.
+func Optimize(input io.Reader, filename string, output io.Writer, options Options) (OptimizeStats, error) {
+	pp := newPreprocessor(options.opener())
+	lines, err := pp.read(input, filename, 0)
+	if err != nil {
+		return OptimizeStats{}, fmt.Errorf("can't read %s: %w", filename, err)
+	}
+
+	if err := pp.errors.err(); err != nil {
+		return OptimizeStats{}, err
+	}
+
+	lines, stats, errors := optimize(lines)
+	if err := errors.err(); err != nil {
+		return OptimizeStats{}, err
+	}
+
+	var builder strings.Builder
+	for _, line := range lines {
+		builder.WriteString(strings.TrimSpace(stripComment(line.text)))
+		builder.WriteRune('\n')
+	}
+
+	_, err = io.WriteString(output, builder.String())
+	return stats, err
+}
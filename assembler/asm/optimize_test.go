@@ -0,0 +1,127 @@
+package asm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		stats  OptimizeStats
+	}{
+		{
+			name:   "redundant reload",
+			before: "@X\nM=D\n@X\nD=M+D\n",
+			after:  "@X\nM=D\nD=M+D\n",
+			stats:  OptimizeStats{Before: 4, After: 3, Reloads: 1},
+		},
+		{
+			name:   "reload after the dest A",
+			before: "@X\nA=M\n@X\nD=M\n",
+			after:  "@X\nA=M\n@X\nD=M\n",
+			stats:  OptimizeStats{Before: 4, After: 4},
+		},
+		{
+			name:   "reload at the label target",
+			before: "@X\nM=D\n(LOOP)\n@X\nM=M-1\n@LOOP\n0;JMP\n",
+			after:  "@X\nM=D\n(LOOP)\n@X\nM=M-1\n@LOOP\n0;JMP\n",
+			stats:  OptimizeStats{Before: 6, After: 6},
+		},
+		{
+			name:   "push and pop",
+			before: "@SP\nAM=M+1\nA=A-1\nM=D\n@SP\nAM=M-1\nD=M\n@R13\nM=D\n",
+			after:  "@R13\nM=D\n",
+			stats:  OptimizeStats{Before: 9, After: 2, PushPops: 7},
+		},
+		{
+			name:   "push and pop of the VM translator",
+			before: "@SP\nA=M\nM=D\n@SP\nM=M+1\n@SP\nM=M-1\nA=M\nD=M\n@R13\nM=D\n",
+			after:  "@R13\nM=D\n",
+			stats:  OptimizeStats{Before: 11, After: 2, PushPops: 9},
+		},
+		{
+			name:   "push and pop without the following A-instruction",
+			before: "@SP\nAM=M+1\nA=A-1\nM=D\n@SP\nAM=M-1\nD=M\nM=D\n",
+			after:  "@SP\nAM=M+1\nA=A-1\nM=D\n@SP\nAM=M-1\nD=M\nM=D\n",
+			stats:  OptimizeStats{Before: 8, After: 8},
+		},
+		{
+			name:   "push and pop split by the label target",
+			before: "@SP\nAM=M+1\nA=A-1\nM=D\n(POP)\n@SP\nAM=M-1\nD=M\n@POP\n0;JMP\n",
+			after:  "@SP\nAM=M+1\nA=A-1\nM=D\n(POP)\n@SP\nAM=M-1\nD=M\n@POP\n0;JMP\n",
+			stats:  OptimizeStats{Before: 9, After: 9},
+		},
+		{
+			name:   "jump to the next instruction",
+			before: "@NEXT\n0;JMP\n(NEXT)\n@R0\nD=M\n",
+			after:  "@R0\nD=M\n",
+			stats:  OptimizeStats{Before: 4, After: 2, Jumps: 2, Labels: 1},
+		},
+		{
+			name:   "jump to the next C-instruction",
+			before: "@NEXT\nD;JGT\n(NEXT)\nD=A\n",
+			after:  "@NEXT\nD;JGT\n(NEXT)\nD=A\n",
+			stats:  OptimizeStats{Before: 3, After: 3},
+		},
+		{
+			name:   "jump with the dest",
+			before: "@NEXT\nM=D;JMP\n(NEXT)\n@R0\n",
+			after:  "@NEXT\nM=D;JMP\n(NEXT)\n@R0\n",
+			stats:  OptimizeStats{Before: 3, After: 3},
+		},
+		{
+			name:   "unreferenced label",
+			before: "(UNUSED)\n@R0\nD=M\n(USED)\n@USED\n0;JMP\n",
+			after:  "@R0\nD=M\n(USED)\n@USED\n0;JMP\n",
+			stats:  OptimizeStats{Before: 4, After: 4, Labels: 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var output strings.Builder
+			stats, err := Optimize(strings.NewReader(test.before), "test.asm", &output, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if output.String() != test.after {
+				t.Errorf("optimized to\n%s\nexpected\n%s", output.String(), test.after)
+			}
+
+			if stats != test.stats {
+				t.Errorf("stats %+v, expected %+v", stats, test.stats)
+			}
+		})
+	}
+}
+
+func TestOptimizeComputedLabel(t *testing.T) {
+	_, err := Optimize(strings.NewReader("(LOOP)\n@LOOP+2\n0;JMP\n"), "test.asm", &strings.Builder{}, Options{})
+	if err == nil || !strings.Contains(err.Error(), `computed from the label "LOOP"`) {
+		t.Errorf("expected the error of the computed label, got %v", err)
+	}
+}
+
+func TestCheckLabelTargets(t *testing.T) {
+	program := []*instruction{
+		{kind: lCommand, symbol: "LOOP"},
+		{kind: aCommand, command: "@NEXT", removed: removedNoop},
+		{kind: cCommand, command: "0;JMP", removed: removedNoop},
+		{kind: aCommand, command: "@X", removed: removedRedundant},
+		{kind: cCommand, command: "M=D"},
+	}
+
+	errors := checkLabelTargets(program)
+	if len(errors) != 1 || !strings.Contains(errors[0].Error(), `removed "@X", the target of the label "LOOP"`) {
+		t.Errorf("expected the removed target of LOOP, got %v", errors)
+	}
+
+	program[3].removed = kept
+	if errors := checkLabelTargets(program); len(errors) != 0 {
+		t.Errorf("expected no errors, got %v", errors)
+	}
+}
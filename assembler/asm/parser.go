@@ -0,0 +1,370 @@
+package asm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// commandType represents command type
+type commandType int
+
+const (
+	// aCommand is an addressing instruction
+	//   Format: @value
+	// Where value is either a non-negative decimal number
+	// or a symbol referring to such number.
+	aCommand commandType = iota
+	// cCommand is a compute instruction
+	//   Format: dest=comp;jump
+	// Either the dest or jump fields may be empty.
+	// If dest is empty, the '=' is omitted;
+	// If jump is empty, the ';' is omitted.
+	cCommand
+	// lCommand pseudo-command binds the Symbol to the memory location into which
+	// the next command in the program will be stored.
+	//   Format: (Symbol)
+	lCommand
+	// directive of the assembler processed during the first pass, see parseDirective
+	//   Format: .name arguments
+	directive
+)
+
+// firstRAMAddress represents first available RAM address that is used to store variables
+const firstRAMAddress = 0x10
+
+// parser reads an assembly language command, parses it,
+// and provides convenient access to the command's components (fields and symbols).
+// In addition, removes all white space and comments.
+type parser struct {
+	lines []sourceLine
+	next  int
+	// code is the current line without the comment
+	code    string
+	command string
+	tokens  []token
+	// lexErr is the error of the lexer at the current line
+	lexErr error
+	// generated is true for lines expanded from pseudo-instructions, which have no columns
+	generated bool
+	text      string
+	pos       Position
+	*ramAllocator
+	// declared contains positions of the labels, constants and variables declared in the source
+	declared map[string]Position
+	// strict rejects the implicit declaration of variables
+	strict bool
+	errors ErrorList
+	// object collects the relocations if the module is assembled into the object file
+	object *objectModule
+	// reference is the relocated symbol of the current A-instruction in the object file
+	reference ObjectWord
+}
+
+// cInstruction contains the mnemonics of the parsed C-instruction.
+// Missing dest and jump are empty.
+type cInstruction struct {
+	dest, comp, jump mnemonic
+}
+
+// mnemonic is the text of the C-instruction field with its column
+type mnemonic struct {
+	text   string
+	column int
+}
+
+// newParser gets ready to parse the preprocessed lines.
+// The errors of the preprocessor are kept.
+func newParser(lines []sourceLine, errors ErrorList, strict bool) *parser {
+	return &parser{
+		lines:        lines,
+		ramAllocator: newRAMAllocator(),
+		declared:     map[string]Position{},
+		strict:       strict,
+		errors:       errors,
+	}
+}
+
+// hasMoreCommands returns true if there are more commands in the input.
+// Skips empty lines and comments.
+func (p *parser) hasMoreCommands() bool {
+	for ; p.next < len(p.lines); p.next++ {
+		if !ignoreCommand(p.lines[p.next].text) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// advance reads the next command from the input and makes it the current command.
+// Should be called only if hasMoreCommands() is true.
+// Initially there is no current command.
+func (p *parser) advance() {
+	line := p.lines[p.next]
+	p.next++
+
+	// Removes whitespaces and comments around the command
+	p.code = stripComment(line.text)
+	p.command = strings.TrimSpace(p.code)
+	p.tokens, p.lexErr = lex(p.code)
+	p.generated = line.source != ""
+	p.text = line.source
+	if p.text == "" {
+		p.text = strings.TrimSpace(line.text)
+	}
+	p.pos = line.pos
+	p.reference = ObjectWord{}
+}
+
+// ignoreCommand defines which types of commands to ignore
+func ignoreCommand(command string) bool {
+	command = strings.TrimSpace(command)
+	return command == "" || strings.HasPrefix(command, "//")
+}
+
+// errorf records an error at the current line of the input.
+func (p *parser) errorf(format string, args ...interface{}) {
+	p.errors = append(p.errors, &Error{p.pos, fmt.Sprintf(format, args...)})
+}
+
+// errorfAt records an error at the column of the current line.
+// Lines expanded from pseudo-instructions are reported without the column.
+func (p *parser) errorfAt(column int, format string, args ...interface{}) {
+	pos := p.pos
+	if !p.generated {
+		pos.Column = column
+	}
+
+	p.errors = append(p.errors, &Error{pos, fmt.Sprintf(format, args...)})
+}
+
+// checkTokens records the error of the lexer at the current line.
+// Returns false if the line can't be lexed.
+func (p *parser) checkTokens() bool {
+	if err, ok := p.lexErr.(*lexError); ok {
+		p.errorfAt(err.column, "%s", err.message)
+		return false
+	}
+
+	return true
+}
+
+// commandType returns the type of the current command given by its first token:
+//   aCommand for @Xxx where Xxx is either a symbol or a decimal number
+//   cCommand for dest=comp;jump
+//   lCommand (actually, pseudo-command) for (Xxx) where Xxx is a symbol.
+func (p *parser) commandType() commandType {
+	if len(p.tokens) == 0 {
+		return cCommand
+	}
+
+	first := p.tokens[0]
+
+	switch {
+	case first.is("@"):
+		return aCommand
+	case first.is("("):
+		return lCommand
+	case first.kind == identifier && strings.HasPrefix(first.text, "."):
+		return directive
+	default:
+		return cCommand
+	}
+}
+
+// symbol returns the symbol, decimal or expression Xxx of the current command @Xxx.
+// Should be called only when commandType() is aCommand.
+func (p *parser) symbol() string {
+	return strings.TrimSpace(p.code[p.tokens[0].column:])
+}
+
+// label returns the symbol Xxx of the current command (Xxx).
+// Should be called only when commandType() is lCommand.
+// The ok is false if the label is malformed.
+func (p *parser) label() (label string, ok bool) {
+	if p.lexErr != nil || len(p.tokens) != 3 || p.tokens[1].kind != identifier || !p.tokens[2].is(")") {
+		return "", false
+	}
+
+	return p.tokens[1].text, isValidSymbol(p.tokens[1].text)
+}
+
+// parseCCommand parses the tokens of the current command
+//   Format: [dest=]comp[;jump]
+// The comp consists of identifiers, numbers and the operators + - ! & |, or of the raw
+// comp bits comp(0b...), spaces between them are removed. Syntax errors are recorded and ok is false.
+// Should be called only when commandType() is cCommand.
+func (p *parser) parseCCommand() (instruction cInstruction, ok bool) {
+	if !p.checkTokens() {
+		return instruction, false
+	}
+
+	tokens := p.tokens
+	if len(tokens) >= 2 && tokens[1].is("=") {
+		if tokens[0].kind != identifier {
+			p.errorfAt(tokens[0].column, "expected dest mnemonic before '=', got %q", tokens[0].text)
+			return instruction, false
+		}
+
+		instruction.dest = mnemonic{tokens[0].text, tokens[0].column}
+		tokens = tokens[2:]
+	}
+
+	var comp strings.Builder
+	raw := len(tokens) > 1 && tokens[0].text == "comp" && tokens[1].is("(")
+	for len(tokens) > 0 && !tokens[0].is(";") {
+		t := tokens[0]
+		parenthesis := raw && (t.is("(") || t.is(")"))
+		if t.kind == character || t.kind == operator && strings.IndexByte("+-!&|", t.text[0]) == -1 && !parenthesis {
+			p.errorfAt(t.column, "unexpected %q in C-instruction", t.text)
+			return instruction, false
+		}
+
+		if comp.Len() == 0 {
+			instruction.comp.column = t.column
+		}
+
+		comp.WriteString(t.text)
+		tokens = tokens[1:]
+	}
+
+	instruction.comp.text = comp.String()
+	if instruction.comp.text == "" {
+		column := len(p.code) + 1
+		if len(tokens) > 0 {
+			column = tokens[0].column
+		}
+
+		p.errorfAt(column, "missing comp mnemonic")
+		return instruction, false
+	}
+
+	if len(tokens) == 0 {
+		return instruction, true
+	}
+
+	if len(tokens) < 2 || tokens[1].kind != identifier {
+		column := len(p.code) + 1
+		if len(tokens) > 1 {
+			column = tokens[1].column
+		}
+
+		p.errorfAt(column, "expected jump mnemonic after ';'")
+		return instruction, false
+	}
+
+	if len(tokens) > 2 {
+		p.errorfAt(tokens[2].column, "unexpected %q after jump mnemonic", tokens[2].text)
+		return instruction, false
+	}
+
+	instruction.jump = mnemonic{tokens[1].text, tokens[1].column}
+	return instruction, true
+}
+
+// getFreeRAMAddress return next free RAM address which is used for storing the variable
+func (p *parser) getFreeRAMAddress(name string) uint16 { return p.allocate(p.pos, name, 1) }
+
+// ramAllocator allocates RAM addresses of the variables
+type ramAllocator struct {
+	ramAddress uint16
+	// reserved contains RAM addresses of the allocated and explicitly placed variables
+	reserved map[uint16]bool
+	// blocks contains the allocated and explicitly placed variables in the order of declarations
+	blocks []ramBlock
+}
+
+// newRAMAllocator creates the allocator starting at the first RAM address for variables.
+func newRAMAllocator() *ramAllocator {
+	return &ramAllocator{ramAddress: firstRAMAddress, reserved: map[uint16]bool{}}
+}
+
+// allocate returns the first address of the next free RAM block of the given size
+// for the variable declared at the position. Explicitly placed variables are skipped.
+func (r *ramAllocator) allocate(pos Position, name string, size int) uint16 {
+	start := r.ramAddress
+	for i := uint16(0); int(i) < size; {
+		if r.reserved[start+i] {
+			start, i = start+i+1, 0
+			continue
+		}
+		i++
+	}
+
+	for i := uint16(0); int(i) < size; i++ {
+		r.reserved[start+i] = true
+	}
+
+	r.ramAddress = start + uint16(size)
+	r.blocks = append(r.blocks, ramBlock{pos, name, start, size, false})
+	return start
+}
+
+// place reserves the address of the variable explicitly placed at the position
+func (r *ramAllocator) place(pos Position, name string, address uint16) {
+	r.reserved[address] = true
+	r.blocks = append(r.blocks, ramBlock{pos, name, address, 1, true})
+}
+
+// declare adds the symbol declared at the current line into the table.
+// Duplicate declarations and redefinitions of predefined symbols are recorded as errors
+// and ok is false.
+func (p *parser) declare(table SymbolTable, name string, symbol Symbol) (ok bool) {
+	switch {
+	case p.declared[name].Line != 0:
+		p.errorf("duplicate symbol %q, previously declared at %s", name, p.declared[name])
+	case table.contains(name):
+		p.errorf("symbol %q redefines a predefined symbol", name)
+	default:
+		table[name] = symbol
+		p.declared[name] = p.pos
+		return true
+	}
+
+	return false
+}
+
+// parseSymbols returns populated symboltable.SymbolTable with parser.LCommands
+// and prepares parser for another file scan.
+// Malformed and duplicate labels are recorded as errors of the parser.
+// The ok is false if the program doesn't fit into the ROM, the scan stops
+// at the first instruction outside of the ROM, so label addresses don't overflow.
+// Labels following the last instruction of the full ROM are errors, their address
+// isn't a valid A-instruction.
+func (p *parser) parseSymbols() (table SymbolTable, ok bool) {
+	table = newSymbolTable()
+	address := 0
+
+	for p.hasMoreCommands() {
+		p.advance()
+		switch p.commandType() {
+		case aCommand, cCommand:
+			if address == ROMSize {
+				p.errorf("instruction doesn't fit into the ROM of %d words", ROMSize)
+				return table, false
+			}
+			address++
+		case lCommand:
+			label, ok := p.label()
+			if !ok {
+				p.errorf("malformed label declaration %q", p.command)
+				continue
+			}
+
+			if address == ROMSize {
+				p.errorf("label %s at address %d is outside of the ROM of %d words", label, address, ROMSize)
+				continue
+			}
+
+			if p.declare(table, label, Symbol{uint16(address), Label}) && p.object != nil {
+				p.object.relocations[label] = RelocateLabel
+			}
+		case directive:
+			p.parseDirective(table)
+		}
+	}
+
+	p.next = 0
+
+	return table, true
+}
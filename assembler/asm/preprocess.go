@@ -0,0 +1,345 @@
+package asm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// maxExpansionDepth limits nested macro expansions and includes, which catches recursive macros
+const maxExpansionDepth = 64
+
+// Position represents the location of the line in the source file.
+// Lines expanded from a macro keep the location in the macro definition
+// and refer to the position of the macro call.
+type Position struct {
+	Filename string
+	Line     int
+	// Column is the 1-based byte offset in the line, or 0 if the error concerns the whole line
+	Column int
+	Macro  string
+	Caller *Position
+}
+
+func (p Position) String() string {
+	if p.Line == 0 {
+		return p.Filename
+	}
+
+	if p.Column > 0 {
+		return fmt.Sprintf("%s:%d:%d", p.Filename, p.Line, p.Column)
+	}
+
+	return fmt.Sprintf("%s:%d", p.Filename, p.Line)
+}
+
+// sourceLine represents a single line of the assembly with its location.
+// Lines expanded from a pseudo-instruction keep its text in the source.
+type sourceLine struct {
+	text   string
+	pos    Position
+	source string
+}
+
+// macro represents a parameterized sequence of lines.
+//   Format: .macro NAME param1, param2
+//           ...
+//           .endm
+// Parameters are referenced as %param1 in the body of the macro.
+// Labels declared in the body are local to each expansion.
+type macro struct {
+	name       string
+	parameters []string
+	body       []sourceLine
+	pos        Position
+}
+
+// preprocessor reads the .asm files, processes the .include directives
+// and expands the macros.
+type preprocessor struct {
+	open       func(filename string) (io.ReadCloser, error)
+	macros     map[string]*macro
+	including  map[string]bool
+	expansions int
+	errors     ErrorList
+}
+
+// newPreprocessor creates a preprocessor without any macros.
+// The open is used to open the included files.
+func newPreprocessor(open func(filename string) (io.ReadCloser, error)) *preprocessor {
+	return &preprocessor{open: open, macros: map[string]*macro{}, including: map[string]bool{}}
+}
+
+// errorf records an error at the given position.
+func (pp *preprocessor) errorf(pos Position, format string, args ...interface{}) {
+	pp.errors = append(pp.errors, &Error{pos, fmt.Sprintf(format, args...)})
+}
+
+// readFile returns preprocessed lines of the file.
+func (pp *preprocessor) readFile(filename string, depth int) ([]sourceLine, error) {
+	f, err := pp.open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return pp.read(f, filename, depth)
+}
+
+// read returns preprocessed lines of the input, the filename is used in positions
+// of the lines and to resolve the included files.
+func (pp *preprocessor) read(input io.Reader, filename string, depth int) ([]sourceLine, error) {
+	var lines []sourceLine
+
+	scanner := bufio.NewScanner(input)
+	for line := 1; scanner.Scan(); line++ {
+		lines = append(lines, sourceLine{text: scanner.Text(), pos: Position{Filename: filename, Line: line}})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	pp.including[filepath.Clean(filename)] = true
+	defer delete(pp.including, filepath.Clean(filename))
+
+	return pp.process(lines, depth), nil
+}
+
+// process returns the lines with processed directives and expanded macros.
+func (pp *preprocessor) process(lines []sourceLine, depth int) []sourceLine {
+	var output []sourceLine
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		fields := strings.Fields(stripComment(line.text))
+		if len(fields) == 0 {
+			output = append(output, line)
+			continue
+		}
+
+		switch name := fields[0]; {
+		case name == ".include":
+			output = append(output, pp.include(line, depth)...)
+		case name == ".macro":
+			i = pp.define(lines, i)
+		case name == ".endm":
+			pp.errorf(line.pos, ".endm without .macro")
+		case pp.macros[name] != nil:
+			output = append(output, pp.expand(pp.macros[name], line, depth)...)
+		default:
+			if expanded, ok := pp.expandPseudo(line); ok {
+				output = append(output, expanded...)
+			} else {
+				output = append(output, line)
+			}
+		}
+	}
+
+	return output
+}
+
+// include returns preprocessed lines of the file included by the line.
+//   Format: .include "file.asm"
+// The path is relative to the directory of the including file.
+func (pp *preprocessor) include(line sourceLine, depth int) []sourceLine {
+	argument := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(stripComment(line.text)), ".include"))
+	if len(argument) < 2 || argument[0] != '"' || argument[len(argument)-1] != '"' {
+		pp.errorf(line.pos, `expected .include "file.asm"`)
+		return nil
+	}
+
+	filename := filepath.Join(filepath.Dir(line.pos.Filename), argument[1:len(argument)-1])
+
+	if pp.including[filename] || depth >= maxExpansionDepth {
+		pp.errorf(line.pos, "recursive include of %s", filename)
+		return nil
+	}
+
+	lines, err := pp.readFile(filename, depth+1)
+	if err != nil {
+		pp.errorf(line.pos, "can't include %s: %v", filename, err)
+		return nil
+	}
+
+	return lines
+}
+
+// define reads the macro definition which starts at lines[start].
+// Returns index of the .endm line.
+func (pp *preprocessor) define(lines []sourceLine, start int) int {
+	header := lines[start]
+	fields := strings.FieldsFunc(stripComment(header.text), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+
+	if len(fields) < 2 || !isValidSymbol(fields[1]) {
+		pp.errorf(header.pos, "expected .macro NAME [parameters]")
+	}
+
+	m := &macro{pos: header.pos}
+	if len(fields) > 1 {
+		m.name = fields[1]
+	}
+
+	for i := 2; i < len(fields); i++ {
+		if !isValidSymbol(fields[i]) {
+			pp.errorf(header.pos, "invalid parameter %q of macro %s", fields[i], m.name)
+		}
+		m.parameters = append(m.parameters, fields[i])
+	}
+
+	end := start + 1
+	for ; end < len(lines); end++ {
+		fields := strings.Fields(stripComment(lines[end].text))
+		if len(fields) > 0 && fields[0] == ".endm" {
+			break
+		}
+		if len(fields) > 0 && fields[0] == ".macro" {
+			pp.errorf(lines[end].pos, "nested macro definition inside macro %s", m.name)
+		}
+
+		m.body = append(m.body, lines[end])
+	}
+
+	switch {
+	case end == len(lines):
+		pp.errorf(header.pos, "macro %s is missing .endm", m.name)
+	case pp.macros[m.name] != nil:
+		pp.errorf(header.pos, "duplicate macro %s, previously defined at %s", m.name, pp.macros[m.name].pos)
+	case m.name != "":
+		pp.macros[m.name] = m
+	}
+
+	return end
+}
+
+// expand returns the body of the macro called by the line with substituted
+// parameters and unique local labels.
+func (pp *preprocessor) expand(m *macro, call sourceLine, depth int) []sourceLine {
+	if depth >= maxExpansionDepth {
+		pp.errorf(call.pos, "recursive expansion of macro %s", m.name)
+		return nil
+	}
+
+	var arguments []string
+	if rest := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(stripComment(call.text)), m.name)); rest != "" {
+		for _, argument := range strings.Split(rest, ",") {
+			arguments = append(arguments, strings.TrimSpace(argument))
+		}
+	}
+
+	if len(arguments) != len(m.parameters) {
+		pp.errorf(call.pos, "macro %s expects %d arguments, got %d", m.name, len(m.parameters), len(arguments))
+		return nil
+	}
+
+	values := map[string]string{}
+	for i, parameter := range m.parameters {
+		values[parameter] = arguments[i]
+	}
+
+	pp.expansions++
+	labels := map[string]string{}
+	for _, line := range m.body {
+		text := strings.TrimSpace(stripComment(line.text))
+		if strings.HasPrefix(text, "(") && strings.HasSuffix(text, ")") {
+			label := text[1 : len(text)-1]
+			labels[label] = fmt.Sprintf("%s$%s.%d", m.name, label, pp.expansions)
+		}
+	}
+
+	caller := call.pos
+	lines := make([]sourceLine, 0, len(m.body))
+
+	for _, line := range m.body {
+		pos := line.pos
+		pos.Macro, pos.Caller = m.name, &caller
+
+		text := stripComment(line.text)
+		fields := strings.Fields(text)
+
+		// Local labels are renamed from the start of the text, which is the whole line of labels,
+		// A-instructions and macro calls, or the operand of the pseudo-instruction expanded later
+		start := len(text)
+		if len(fields) > 0 && (strings.HasPrefix(fields[0], "@") || strings.HasPrefix(fields[0], "(") || pp.macros[fields[0]] != nil) {
+			start = 0
+		} else if offset, ok := pseudoOperand(strings.TrimSpace(text)); ok {
+			start = len(text) - len(strings.TrimLeft(text, " \t")) + offset
+		}
+
+		replace := func(renameLabels bool) func(string, bool) string {
+			return func(identifier string, isParameter bool) string {
+				if isParameter {
+					value, ok := values[identifier]
+					if !ok {
+						pp.errorf(pos, "unknown parameter %%%s of macro %s", identifier, m.name)
+					}
+					return value
+				}
+				if label, ok := labels[identifier]; ok && renameLabels {
+					return label
+				}
+				return identifier
+			}
+		}
+
+		text = replaceIdentifiers(text[:start], replace(false)) + replaceIdentifiers(text[start:], replace(true))
+
+		lines = append(lines, sourceLine{text: text, pos: pos})
+	}
+
+	return pp.process(lines, depth+1)
+}
+
+// replaceIdentifiers replaces every symbol of the text by the result of the replace.
+// Parameters (%param) are passed without the leading percent sign.
+// Numbers and quoted text are kept untouched.
+func replaceIdentifiers(text string, replace func(identifier string, isParameter bool) string) string {
+	var builder strings.Builder
+
+	for i := 0; i < len(text); {
+		char := text[i]
+
+		switch {
+		case char == '\'' || char == '"':
+			end := strings.IndexByte(text[i+1:], char)
+			if end == -1 {
+				builder.WriteString(text[i:])
+				return builder.String()
+			}
+			builder.WriteString(text[i : i+end+2])
+			i += end + 2
+
+		case char == '%' || isSymbolCharacter(char):
+			start := i
+			if char == '%' {
+				i++
+			}
+			for i < len(text) && (isSymbolCharacter(text[i]) || isDigit(text[i])) {
+				i++
+			}
+
+			if char == '%' {
+				builder.WriteString(replace(text[start+1:i], true))
+			} else {
+				builder.WriteString(replace(text[start:i], false))
+			}
+
+		case isDigit(char):
+			start := i
+			for i < len(text) && (isSymbolCharacter(text[i]) || isDigit(text[i])) {
+				i++
+			}
+			builder.WriteString(text[start:i])
+
+		default:
+			builder.WriteByte(char)
+			i++
+		}
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,118 @@
+package asm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Pseudo-instructions expanded into the native A- and C-instructions
+//   goto LABEL             @LABEL, 0;JMP
+//   if D>0 goto LABEL      @LABEL, D;JGT (also D>=0, D<0, D<=0, D=0, D==0, D!=0, D<>0)
+//   D=<const>              @const, D=A for constants which aren't native comp mnemonics
+//   D=-<const>             @const, D=-A
+//   push D                 @SP, AM=M+1, A=A-1, M=D
+//   pop D                  @SP, AM=M-1, D=M
+//   call LABEL             pushes the return address and jumps to the LABEL
+//   ret                    pops the return address and jumps to it
+var (
+	ifGotoPattern   = regexp.MustCompile(`^if\s+D\s*(>=|<=|==|!=|<>|=|>|<)\s*0\s+goto\s+(\S+)$`)
+	gotoPattern     = regexp.MustCompile(`^goto\s+(\S+)$`)
+	callPattern     = regexp.MustCompile(`^call\s+(\S+)$`)
+	constantPattern = regexp.MustCompile(`^D\s*=\s*(-?)\s*([0-9'(].*)$`)
+	// macroConstantPattern also matches constants starting with a macro parameter
+	macroConstantPattern = regexp.MustCompile(`^D\s*=\s*-?\s*([0-9'(%].*)$`)
+)
+
+// conditionJumps maps comparisons of D with zero to the jump mnemonics
+var conditionJumps = map[string]string{
+	">":  "JGT",
+	">=": "JGE",
+	"<":  "JLT",
+	"<=": "JLE",
+	"=":  "JEQ",
+	"==": "JEQ",
+	"!=": "JNE",
+	"<>": "JNE",
+}
+
+// pushD pushes the D register onto the stack
+var pushD = []string{"@SP", "AM=M+1", "A=A-1", "M=D"}
+
+// expandPseudo returns the native instructions of the pseudo-instruction at the line.
+// The ok is false if the line isn't a pseudo-instruction.
+func (pp *preprocessor) expandPseudo(line sourceLine) (lines []sourceLine, ok bool) {
+	command := strings.TrimSpace(stripComment(line.text))
+
+	var instructions []string
+
+	if match := ifGotoPattern.FindStringSubmatch(command); match != nil {
+		instructions = []string{"@" + match[2], "D;" + conditionJumps[match[1]]}
+	} else if match := gotoPattern.FindStringSubmatch(command); match != nil {
+		instructions = []string{"@" + match[1], "0;JMP"}
+	} else if match := callPattern.FindStringSubmatch(command); match != nil {
+		pp.expansions++
+		returnLabel := fmt.Sprintf("%s$call.%d", match[1], pp.expansions)
+
+		instructions = append(append([]string{"@" + returnLabel, "D=A"}, pushD...),
+			"@"+match[1], "0;JMP", "("+returnLabel+")")
+	} else if match := constantPattern.FindStringSubmatch(command); match != nil && !isNativeComp(command) {
+		comp := "D=A"
+		if match[1] == "-" {
+			comp = "D=-A"
+		}
+
+		instructions = []string{"@" + strings.TrimSpace(match[2]), comp}
+	} else {
+		switch strings.Join(strings.Fields(command), " ") {
+		case "push D":
+			instructions = pushD
+		case "pop D":
+			instructions = []string{"@SP", "AM=M-1", "D=M"}
+		case "ret":
+			instructions = []string{"@SP", "AM=M-1", "A=M", "0;JMP"}
+		default:
+			return nil, false
+		}
+	}
+
+	source := strings.TrimSpace(line.text)
+	for _, instruction := range instructions {
+		lines = append(lines, sourceLine{text: instruction, pos: line.pos, source: source})
+	}
+
+	return lines, true
+}
+
+// isNativeComp checks if the D=comp command, optionally followed by the jump, is a native C-instruction
+func isNativeComp(command string) bool {
+	comp := command[strings.IndexByte(command, '=')+1:]
+	if end := strings.IndexByte(comp, ';'); end != -1 {
+		comp = comp[:end]
+	}
+
+	comp = strings.Join(strings.Fields(comp), "")
+	_, ok := getCompBinary(comp)
+	return ok
+}
+
+// pseudoOperand returns the offset of the label or constant operand of the goto, if-goto, call
+// or D=<const> pseudo-instruction in the command of the macro body, which may contain parameters.
+// The ok is false if the command isn't such a pseudo-instruction.
+func pseudoOperand(command string) (offset int, ok bool) {
+	if match := ifGotoPattern.FindStringSubmatchIndex(command); match != nil {
+		return match[4], true
+	}
+
+	for _, pattern := range []*regexp.Regexp{gotoPattern, callPattern} {
+		if match := pattern.FindStringSubmatchIndex(command); match != nil {
+			return match[2], true
+		}
+	}
+
+	if match := macroConstantPattern.FindStringSubmatchIndex(command); match != nil && !isNativeComp(command) {
+		return match[2], true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,72 @@
+package asm
+
+import "testing"
+
+func TestMacroLocalLabelsInPseudoInstructions(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		// jumps maps the addresses of the A-instructions to the expected jump targets
+		jumps map[int]uint16
+	}{
+		{
+			name: "goto and if-goto",
+			source: `.macro COUNT
+(LOOP)
+D=D-1
+if D>0 goto LOOP
+goto DONE
+(DONE)
+.endm
+D=5
+COUNT
+(END)
+goto END
+`,
+			jumps: map[int]uint16{3: 2, 5: 7, 7: 7},
+		},
+		{
+			name: "parameter named like a local label",
+			source: `.macro SKIP target
+goto %target
+(LOOP)
+goto LOOP
+.endm
+(LOOP)
+SKIP LOOP
+`,
+			jumps: map[int]uint16{0: 0, 2: 2},
+		},
+		{
+			name: "call",
+			source: `.macro CALL
+call LOCAL
+(LOCAL)
+.endm
+CALL
+`,
+			jumps: map[int]uint16{6: 8},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			program, err := AssembleString(test.source, "test.asm", Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for name, symbol := range program.Symbols {
+				if symbol.Kind == Variable {
+					t.Errorf("label %s became a variable at RAM[%d]", name, symbol.Address)
+				}
+			}
+
+			for address, target := range test.jumps {
+				if word := program.Words[address]; word != target {
+					t.Errorf("ROM[%d] = @%d, expected @%d", address, word, target)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package asm
+
+import "github.com/ProchazkaDavid/nand2tetris/sourcemap"
+
+// SourceMap returns the map of the ROM addresses to the lines of the assembly.
+// Instructions expanded from macros are mapped to the line of the outermost macro call.
+func (p *Program) SourceMap() sourcemap.Map {
+	var m sourcemap.Map
+
+	for _, line := range p.Listing {
+		if !line.Instruction {
+			continue
+		}
+
+		pos := line.Pos
+		for pos.Caller != nil {
+			pos = *pos.Caller
+		}
+
+		m = append(m, sourcemap.Entry{Target: int(line.Address), Source: sourcemap.Position{Filename: pos.Filename, Line: pos.Line}})
+	}
+
+	return m
+}
@@ -0,0 +1,103 @@
+package asm
+
+import "strings"
+
+// SymbolKind represents how the symbol was defined
+type SymbolKind int
+
+const (
+	// Predefined symbol is defined by the Hack platform (SP, R0, SCREEN, ...)
+	Predefined SymbolKind = iota
+	// Label is declared by the (LABEL) pseudo-command and refers to the ROM address
+	Label
+	// Variable is allocated in the RAM by its first use in the A-instruction
+	// or declared by the .var and .block directives
+	Variable
+	// Constant is declared by the .equ directive
+	Constant
+)
+
+var symbolKindNames = map[SymbolKind]string{
+	Predefined: "predefined",
+	Label:      "label",
+	Variable:   "variable",
+	Constant:   "constant",
+}
+
+func (k SymbolKind) String() string { return symbolKindNames[k] }
+
+// Symbol represents the address of the symbol and how it was defined
+type Symbol struct {
+	Address uint16
+	Kind    SymbolKind
+}
+
+// SymbolTable keeps a correspondence between symbolic labels and numeric addresses.
+type SymbolTable map[string]Symbol
+
+// newSymbolTable creates a new symbol table populated with predefined symbols.
+func newSymbolTable() SymbolTable {
+	table := SymbolTable{}
+	for symbol, address := range predefinedSymbols {
+		table[symbol] = Symbol{address, Predefined}
+	}
+
+	return table
+}
+
+// predefinedSymbols maps symbols predefined by the Hack platform to their addresses
+var predefinedSymbols = map[string]uint16{
+	"SP":     0x0000,
+	"LCL":    0x0001,
+	"ARG":    0x0002,
+	"THIS":   0x0003,
+	"THAT":   0x0004,
+	"R0":     0x0000,
+	"R1":     0x0001,
+	"R2":     0x0002,
+	"R3":     0x0003,
+	"R4":     0x0004,
+	"R5":     0x0005,
+	"R6":     0x0006,
+	"R7":     0x0007,
+	"R8":     0x0008,
+	"R9":     0x0009,
+	"R10":    0x000A,
+	"R11":    0x000B,
+	"R12":    0x000C,
+	"R13":    0x000D,
+	"R14":    0x000E,
+	"R15":    0x000F,
+	"SCREEN": 0x4000,
+	"KBD":    0x6000,
+}
+
+// contains returns true if the symbol is already defined in the table.
+func (t SymbolTable) contains(symbol string) bool {
+	_, ok := t[symbol]
+	return ok
+}
+
+// isValidSymbol checks that the symbol is a sequence of letters, digits, underscore (_),
+// dot (.), dollar sign ($), and colon (:) that does not begin with a digit.
+func isValidSymbol(symbol string) bool {
+	if symbol == "" || isDigit(symbol[0]) {
+		return false
+	}
+
+	for i := 0; i < len(symbol); i++ {
+		if !isDigit(symbol[i]) && !isSymbolCharacter(symbol[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isSymbolCharacter checks if the char can be used anywhere in a symbol.
+func isSymbolCharacter(char byte) bool {
+	return 'a' <= char && char <= 'z' || 'A' <= char && char <= 'Z' || strings.IndexByte("_.$:", char) != -1
+}
+
+// isDigit checks if the char is a decimal digit.
+func isDigit(char byte) bool { return '0' <= char && char <= '9' }
@@ -0,0 +1,109 @@
+package asm
+
+// maxConstant is the largest value that fits into the 15-bit address of the A-instruction
+const maxConstant = 1<<15 - 1
+
+// translateACommand returns the binary code of parser's current ACommand based on the table.
+// The address is either a symbol or a constant expression, see expression.
+// Invalid addresses are recorded as errors of the parser and ok is false.
+func translateACommand(p *parser, table SymbolTable) (binary uint16, ok bool) {
+	if !p.checkTokens() {
+		return 0, false
+	}
+
+	operand := p.symbol()
+
+	if operand == "" {
+		p.errorf("missing address in A-instruction")
+		return 0, false
+	}
+
+	if isValidSymbol(operand) {
+		// Known symbol or variable in SymbolTable
+		if entry, ok := table[operand]; ok {
+			if p.object != nil && p.object.relocations[operand] != Absolute {
+				p.reference = ObjectWord{Relocation: p.object.relocations[operand], Symbol: operand}
+			}
+
+			return entry.Address, true
+		}
+
+		if p.strict {
+			p.errorf("undefined symbol %q, implicit variables are disabled", operand)
+			return 0, false
+		}
+
+		// Unknown symbol, declaration of a new variable
+		if p.object != nil {
+			table[operand] = Symbol{0, Variable}
+			p.object.relocations[operand] = RelocateVariable
+			p.object.variables = append(p.object.variables, ObjectVariable{Name: operand, Size: 1})
+			p.reference = ObjectWord{Relocation: RelocateVariable, Symbol: operand}
+			return 0, true
+		}
+
+		table[operand] = Symbol{p.getFreeRAMAddress(operand), Variable}
+		return table[operand].Address, true
+	}
+
+	value, err := evaluate(operand, p.resolver(table))
+	if err != nil {
+		p.errorf("%v", err)
+		return 0, false
+	}
+
+	if value < 0 || value > maxConstant {
+		p.errorf("value %d of %q is outside of the range 0-%d", value, operand, maxConstant)
+		return 0, false
+	}
+
+	return uint16(value), true
+}
+
+// resolver returns the function resolving symbols of expressions by the table.
+// Addresses of the relocatable symbols of object files are known only after
+// the linking, so their use in expressions is recorded as an error.
+func (p *parser) resolver(table SymbolTable) func(symbol string) (int, bool) {
+	return func(symbol string) (int, bool) {
+		entry, ok := table[symbol]
+		if ok && p.object != nil && p.object.relocations[symbol] != Absolute {
+			p.errorf("relocatable symbol %q can't be used in expressions of object files", symbol)
+		}
+
+		return int(entry.Address), ok
+	}
+}
+
+// translateCCommand returns the binary code of parser's current CCommand.
+// Unknown mnemonics are recorded as errors of the parser and ok is false.
+func translateCCommand(p *parser) (binary uint16, ok bool) {
+	instruction, ok := p.parseCCommand()
+	if !ok {
+		return 0, false
+	}
+
+	comp, compOK := getCompBinary(instruction.comp.text)
+	switch {
+	case compOK:
+	case isRawComp(instruction.comp.text):
+		p.errorfAt(instruction.comp.column, "raw comp bits %q must be comp(0b...) with 7 binary digits", instruction.comp.text)
+	default:
+		p.errorfAt(instruction.comp.column, "unknown comp mnemonic %q", instruction.comp.text)
+	}
+
+	dest, destOK := getDestBinary(instruction.dest.text)
+	if !destOK {
+		p.errorfAt(instruction.dest.column, "unknown dest mnemonic %q", instruction.dest.text)
+	}
+
+	jump, jumpOK := getJumpBinary(instruction.jump.text)
+	if !jumpOK {
+		p.errorfAt(instruction.jump.column, "unknown jump mnemonic %q", instruction.jump.text)
+	}
+
+	if !compOK || !destOK || !jumpOK {
+		return 0, false
+	}
+
+	return 0b111<<13 | comp<<6 | dest<<3 | jump, true
+}
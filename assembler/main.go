@@ -1,60 +1,223 @@
 package main
 
 import (
+	"bytes"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/ProchazkaDavid/nand2tetris/assembler/asm"
+	"github.com/ProchazkaDavid/nand2tetris/assembler/rom"
 )
 
 func main() {
-	if len(os.Args) != 2 {
-		log.Fatalln("expected single .asm file")
+	listing := flag.Bool("list", false, "write the listing of ROM addresses, binary code and source lines into the .lst file")
+	sourceMap := flag.Bool("map", false, "write the map of ROM addresses to the source lines into the .rom.map file")
+	symbols := flag.Bool("symbols", false, "write the final symbol table into the .sym file")
+	strict := flag.Bool("strict", false, "reject undeclared symbols instead of creating new variables")
+	optimize := flag.Bool("O", false, "run the peephole optimizer and report the saved instructions")
+	format := flag.String("format", "hack", "output format: "+strings.Join(rom.Names(), ", "))
+	compileOnly := flag.Bool("c", false, "assemble every .asm file into the .obj object file without linking")
+	ramCheck := flag.String("ram", "warn", "report variables outside of the static region 16-255: warn, error or ignore")
+	usage := flag.Bool("usage", false, "print the ROM and RAM usage of the program with the size of every function")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		log.Fatalln("expected .asm or .obj files")
 	}
 
-	if filepath.Ext(os.Args[1]) != ".asm" {
-		log.Fatalln("expected .asm file")
+	for _, filename := range flag.Args() {
+		if ext := filepath.Ext(filename); ext != ".asm" && (ext != ".obj" || *compileOnly) {
+			log.Fatalf("expected .asm file, got %s\n", filename)
+		}
 	}
 
-	if err := run(os.Args[1]); err != nil {
+	outputFormat, err := rom.ParseFormat(*format)
+	if err != nil {
 		log.Fatalln(err)
 	}
+
+	check, err := asm.ParseRAMCheck(*ramCheck)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	options := asm.Options{Strict: *strict, Optimize: *optimize, RAM: check}
+
+	switch {
+	case *compileOnly:
+		err = compile(flag.Args(), options)
+	case flag.NArg() == 1 && filepath.Ext(flag.Arg(0)) == ".asm":
+		err = run(flag.Arg(0), options, outputFormat, debugFiles{*listing, *symbols, *sourceMap}, *usage)
+	case *listing || *sourceMap:
+		err = fmt.Errorf("-list and -map are supported only for a single .asm file")
+	default:
+		err = link(flag.Args(), options, outputFormat, *symbols, *usage)
+	}
+
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// debugFiles selects the files written next to the machine code
+type debugFiles struct {
+	listing   bool
+	symbols   bool
+	sourceMap bool
 }
 
-func run(filename string) error {
-	parser, err := newParser(filename)
+func run(filename string, options asm.Options, format rom.Format, debug debugFiles, usage bool) error {
+	program, err := asm.AssembleFile(filename, options)
 	if err != nil {
-		return fmt.Errorf("can't create a parser: %w", err)
+		return err
+	}
+
+	if options.Optimize {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", filepath.Base(filename), program.Optimized)
+	}
+
+	if err := report(program, usage); err != nil {
+		return err
+	}
+
+	return writeProgram(program, filename, format, debug)
+}
+
+// compile assembles every .asm file into the .obj object file
+func compile(filenames []string, options asm.Options) error {
+	for _, filename := range filenames {
+		object, err := assembleObject(filename, options)
+		if err != nil {
+			return err
+		}
+
+		if err := writeFile(strings.TrimSuffix(filename, filepath.Ext(filename))+".obj", object.WriteObject); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// link assembles the .asm files, reads the .obj files and links them into the program
+// named by the first file
+func link(filenames []string, options asm.Options, format rom.Format, symbols, usage bool) error {
+	var objects []*asm.Object
+
+	for _, filename := range filenames {
+		var object *asm.Object
+		var err error
+
+		if filepath.Ext(filename) == ".obj" {
+			object, err = readObject(filename)
+		} else {
+			object, err = assembleObject(filename, options)
+		}
+		if err != nil {
+			return err
+		}
+
+		objects = append(objects, object)
 	}
 
-	// First pass - populates the symbol table
-	table, err := parser.parseSymbols()
+	program, err := asm.Link(objects, options.RAM)
 	if err != nil {
 		return err
 	}
 
-	hackFile, err := os.Create(strings.TrimSuffix(filename, filepath.Ext(filename)) + ".hack")
+	if err := report(program, usage); err != nil {
+		return err
+	}
+
+	return writeProgram(program, filenames[0], format, debugFiles{symbols: symbols})
+}
+
+// assembleObject assembles the .asm file into the object
+func assembleObject(filename string, options asm.Options) (*asm.Object, error) {
+	f, err := os.Open(filename)
 	if err != nil {
-		return fmt.Errorf("can't create .hack file: %w", err)
+		return nil, err
+	}
+	defer f.Close()
+
+	return asm.AssembleObject(f, filename, options)
+}
+
+// readObject reads the .obj file
+func readObject(filename string) (*asm.Object, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	object, err := asm.ReadObject(f)
+	if err != nil {
+		return nil, fmt.Errorf("can't read %s: %w", filename, err)
+	}
+
+	return object, nil
+}
+
+// report prints the warnings of the program and its usage if requested
+func report(program *asm.Program, usage bool) error {
+	for _, warning := range program.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+
+	if !usage {
+		return nil
 	}
-	defer hackFile.Close()
 
-	// Second pass - writes the final binary code into the .hack file
-	for parser.HasMoreCommands() {
-		parser.Advance()
+	return program.Usage().WriteUsage(os.Stdout)
+}
+
+// writeProgram writes the machine code, the listing, the symbol table and the source map
+// next to the source file
+func writeProgram(program *asm.Program, filename string, format rom.Format, debug debugFiles) error {
+	basename := strings.TrimSuffix(filename, filepath.Ext(filename))
+
+	writeCode := func(w io.Writer) error { return program.WriteFormat(w, format) }
+	if err := writeFile(basename+format.Extension(), writeCode); err != nil {
+		return err
+	}
 
-		switch parser.commandType() {
-		case ACommand:
-			err = writeACommand(hackFile, parser, table)
-		case CCommand:
-			err = writeCCommand(hackFile, parser)
+	if debug.listing {
+		if err := writeFile(basename+".lst", program.WriteListing); err != nil {
+			return err
 		}
+	}
 
-		if err != nil {
+	if debug.symbols {
+		if err := writeFile(basename+".sym", program.Symbols.WriteSymbols); err != nil {
 			return err
 		}
 	}
 
+	if debug.sourceMap {
+		if err := writeFile(basename+".rom.map", program.SourceMap().Write); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeFile creates the file with the content written by the write
+func writeFile(filename string, write func(w io.Writer) error) error {
+	var content bytes.Buffer
+	if err := write(&content); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filename, content.Bytes(), 0644); err != nil {
+		return fmt.Errorf("can't write %s: %w", filepath.Base(filename), err)
+	}
+
 	return nil
 }
@@ -0,0 +1,99 @@
+// Package rom writes and reads the Hack machine code in the formats used
+// by the emulators, HDL simulators and FPGA tools.
+package rom
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Format represents the file format of the machine code
+type Format int
+
+const (
+	// Hack is the text format of the course, one word written as 16 binary digits per line
+	Hack Format = iota
+	// BinaryLE is the raw binary, two bytes per word, the low byte first
+	BinaryLE
+	// BinaryBE is the raw binary, two bytes per word, the high byte first
+	BinaryBE
+	// IntelHex is the Intel HEX with byte addresses, the high byte of the word first
+	IntelHex
+	// ReadMemB is the Verilog memory file for $readmemb, 16 binary digits per line
+	ReadMemB
+	// ReadMemH is the Verilog memory file for $readmemh, 4 hexadecimal digits per line
+	ReadMemH
+	// Logisim is the ROM image of the Logisim memory components (v2.0 raw)
+	Logisim
+)
+
+// formats maps the names of the formats to the formats
+var formats = map[string]Format{
+	"hack":     Hack,
+	"bin-le":   BinaryLE,
+	"bin-be":   BinaryBE,
+	"ihex":     IntelHex,
+	"readmemb": ReadMemB,
+	"readmemh": ReadMemH,
+	"logisim":  Logisim,
+}
+
+// extensions contains the file extensions of the formats
+var extensions = map[Format]string{
+	Hack:     ".hack",
+	BinaryLE: ".bin",
+	BinaryBE: ".bin",
+	IntelHex: ".hex",
+	ReadMemB: ".memb",
+	ReadMemH: ".memh",
+	Logisim:  ".rom",
+}
+
+// ParseFormat returns the format of the given name.
+func ParseFormat(name string) (Format, error) {
+	if format, ok := formats[name]; ok {
+		return format, nil
+	}
+
+	return 0, fmt.Errorf("unknown format %q, expected one of %s", name, strings.Join(Names(), ", "))
+}
+
+// FormatOf returns the format of the file given by its extension.
+// The .bin files are expected to be little-endian.
+func FormatOf(filename string) (Format, error) {
+	ext := filepath.Ext(filename)
+
+	for _, format := range []Format{Hack, BinaryLE, IntelHex, ReadMemB, ReadMemH, Logisim} {
+		if extensions[format] == ext {
+			return format, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown format of %s", filepath.Base(filename))
+}
+
+// Names returns the sorted names of all formats.
+func Names() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func (f Format) String() string {
+	for name, format := range formats {
+		if format == f {
+			return name
+		}
+	}
+
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// Extension returns the file extension of the format including the dot.
+func (f Format) Extension() string { return extensions[f] }
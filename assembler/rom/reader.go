@@ -0,0 +1,291 @@
+package rom
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// maxWords is the number of words addressable by the 16-bit address
+const maxWords = 0x10000
+
+var errAddressRange = fmt.Errorf("address exceeds %d words", maxWords)
+
+// Read reads the words from the input in the given format.
+func Read(input io.Reader, format Format) ([]uint16, error) {
+	switch format {
+	case Hack:
+		return readHack(input)
+	case BinaryLE:
+		return readBinary(input, binary.LittleEndian)
+	case BinaryBE:
+		return readBinary(input, binary.BigEndian)
+	case IntelHex:
+		return readIntelHex(input)
+	case ReadMemB:
+		return readMem(input, 2, 16)
+	case ReadMemH:
+		return readMem(input, 16, 4)
+	case Logisim:
+		return readLogisim(input)
+	default:
+		return nil, fmt.Errorf("unknown format %v", format)
+	}
+}
+
+// ReadFile reads the words from the file in the given format.
+func ReadFile(filename string, format Format) ([]uint16, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Read(f, format)
+}
+
+// readHack reads one word written as 16 binary digits per line, blank lines are skipped
+func readHack(input io.Reader) ([]uint16, error) {
+	var words []uint16
+
+	scanner := bufio.NewScanner(input)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		word, err := strconv.ParseUint(text, 2, 16)
+		if err != nil || len(text) != 16 {
+			return nil, fmt.Errorf("line %d: expected 16 binary digits, got %q", line, text)
+		}
+
+		words = append(words, uint16(word))
+	}
+
+	return words, scanner.Err()
+}
+
+// readBinary reads two bytes per word in the given byte order
+func readBinary(input io.Reader, order binary.ByteOrder) ([]uint16, error) {
+	content, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(content)%2 != 0 {
+		return nil, errors.New("odd number of bytes")
+	}
+
+	words := make([]uint16, len(content)/2)
+	for i := range words {
+		words[i] = order.Uint16(content[2*i:])
+	}
+
+	return words, nil
+}
+
+// memory collects words written at arbitrary addresses, unwritten words are zero
+type memory []uint16
+
+// store writes the word at the address
+func (m *memory) store(address int, word uint16) error {
+	if address >= maxWords {
+		return errAddressRange
+	}
+
+	for len(*m) <= address {
+		*m = append(*m, 0)
+	}
+
+	(*m)[address] = word
+	return nil
+}
+
+// readIntelHex reads the data records up to the end of file record.
+// Extended segment (02) and linear (04) address records are supported.
+func readIntelHex(input io.Reader) ([]uint16, error) {
+	var content []byte
+	base := 0
+
+	scanner := bufio.NewScanner(input)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		record, err := parseIntelHexRecord(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		address, data := base+(int(record[1])<<8|int(record[2])), record[4:len(record)-1]
+
+		switch record[3] {
+		case 0x00:
+			if address+len(data) > 2*maxWords {
+				return nil, fmt.Errorf("line %d: %w", line, errAddressRange)
+			}
+
+			for len(content) < address+len(data) {
+				content = append(content, 0)
+			}
+			copy(content[address:], data)
+		case 0x01:
+			if len(content)%2 != 0 {
+				content = append(content, 0)
+			}
+
+			return readBinary(bytes.NewReader(content), binary.BigEndian)
+		case 0x02, 0x04:
+			if len(data) != 2 {
+				return nil, fmt.Errorf("line %d: invalid extended address record", line)
+			}
+
+			base = (int(data[0])<<8 | int(data[1])) << 4
+			if record[3] == 0x04 {
+				base <<= 12
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, errors.New("missing end of file record")
+}
+
+// parseIntelHexRecord returns the bytes of the record including the checksum
+func parseIntelHexRecord(text string) ([]byte, error) {
+	if !strings.HasPrefix(text, ":") {
+		return nil, fmt.Errorf("expected record starting with ':', got %q", text)
+	}
+
+	record, err := hex.DecodeString(text[1:])
+	if err != nil || len(record) < 5 || len(record) != int(record[0])+5 {
+		return nil, fmt.Errorf("invalid record %q", text)
+	}
+
+	var sum byte
+	for _, b := range record {
+		sum += b
+	}
+
+	if sum != 0 {
+		return nil, fmt.Errorf("invalid checksum of record %q", text)
+	}
+
+	return record, nil
+}
+
+// readMem reads the Verilog memory file of the $readmemb or $readmemh. Words are separated
+// by whitespaces, // starts a comment and @address (hexadecimal) sets the address of the next word.
+func readMem(input io.Reader, base, digits int) ([]uint16, error) {
+	var words memory
+	address := 0
+
+	scanner := bufio.NewScanner(input)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if i := strings.Index(text, "//"); i != -1 {
+			text = text[:i]
+		}
+
+		for _, field := range strings.Fields(text) {
+			if strings.HasPrefix(field, "@") {
+				value, err := strconv.ParseUint(field[1:], 16, 32)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid address %q", line, field)
+				}
+
+				address = int(value)
+				continue
+			}
+
+			value := strings.ReplaceAll(field, "_", "")
+			word, err := strconv.ParseUint(value, base, 16)
+			if err != nil || len(value) > digits {
+				return nil, fmt.Errorf("line %d: expected at most %d digits in base %d, got %q", line, digits, base, field)
+			}
+
+			if err := words.store(address, uint16(word)); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			address++
+		}
+	}
+
+	return words, scanner.Err()
+}
+
+// readLogisim reads the v2.0 raw image of hexadecimal words, count*word repeats the word
+// and # starts a comment.
+func readLogisim(input io.Reader) ([]uint16, error) {
+	var words []uint16
+	header := false
+
+	scanner := bufio.NewScanner(input)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if i := strings.Index(text, "#"); i != -1 {
+			text = text[:i]
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if !header {
+			if strings.Join(fields, " ") != "v2.0 raw" {
+				return nil, fmt.Errorf("line %d: expected v2.0 raw header", line)
+			}
+
+			header = true
+			continue
+		}
+
+		for _, field := range fields {
+			count, value := uint64(1), field
+			if i := strings.Index(field, "*"); i != -1 {
+				var err error
+				if count, err = strconv.ParseUint(field[:i], 10, 32); err != nil {
+					return nil, fmt.Errorf("line %d: invalid count %q", line, field)
+				}
+				value = field[i+1:]
+			}
+
+			word, err := strconv.ParseUint(value, 16, 16)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid word %q", line, field)
+			}
+
+			if uint64(len(words))+count > maxWords {
+				return nil, fmt.Errorf("line %d: %w", line, errAddressRange)
+			}
+
+			for ; count > 0; count-- {
+				words = append(words, uint16(word))
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if !header {
+		return nil, errors.New("missing v2.0 raw header")
+	}
+
+	return words, nil
+}
@@ -0,0 +1,110 @@
+package rom
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	words := []uint16{0x0010, 0xEC10, 0x0000, 0x0000, 0x0000, 0xFFFF, 0x7FFF, 0x8000, 0x1234, 0xE308, 0x0001}
+
+	for _, name := range Names() {
+		t.Run(name, func(t *testing.T) {
+			format, err := ParseFormat(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var output bytes.Buffer
+			if err := Write(&output, words, format); err != nil {
+				t.Fatal(err)
+			}
+
+			read, err := Read(&output, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(read, words) {
+				t.Errorf("read %v, expected %v", read, words)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		format Format
+		words  []uint16
+		output string
+	}{
+		{Hack, []uint16{0x0010, 0xEC10}, "0000000000010000\n1110110000010000\n"},
+		{BinaryLE, []uint16{0x0010, 0xEC10}, "\x10\x00\x10\xEC"},
+		{BinaryBE, []uint16{0x0010, 0xEC10}, "\x00\x10\xEC\x10"},
+		{IntelHex, []uint16{0x0010, 0xEC10}, ":040000000010EC10F0\n:00000001FF\n"},
+		{ReadMemH, []uint16{0x0010, 0xEC10}, "0010\nec10\n"},
+		{Logisim, []uint16{1, 1, 1, 2, 2}, "v2.0 raw\n3*1 2 2\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.format.String(), func(t *testing.T) {
+			var output strings.Builder
+			if err := Write(&output, test.words, test.format); err != nil {
+				t.Fatal(err)
+			}
+
+			if output.String() != test.output {
+				t.Errorf("wrote %q, expected %q", output.String(), test.output)
+			}
+		})
+	}
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		input  string
+		words  []uint16
+		err    string
+	}{
+		{"readmemh address", ReadMemH, "// comment\n@2 ec10\n0001 // word\n", []uint16{0, 0, 0xEC10, 1}, ""},
+		{"readmemb underscores", ReadMemB, "0000_0000_0001_0000\n", []uint16{0x0010}, ""},
+		{"ihex extended address", IntelHex, ":020000020001FB\n:0200000000AA54\n:00000001FF\n", []uint16{0, 0, 0, 0, 0, 0, 0, 0, 0xAA}, ""},
+		{"ihex checksum", IntelHex, ":040000000010EC10F1\n", nil, "invalid checksum"},
+		{"ihex end of file", IntelHex, ":040000000010EC10F0\n", nil, "missing end of file record"},
+		{"logisim header", Logisim, "1 2\n", nil, "expected v2.0 raw header"},
+		{"hack digits", Hack, "0101\n", nil, "line 1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			words, err := Read(strings.NewReader(test.input), test.format)
+
+			switch {
+			case test.err == "" && err != nil:
+				t.Fatal(err)
+			case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+				t.Fatalf("got error %v, expected %q", err, test.err)
+			}
+
+			if test.err == "" && !reflect.DeepEqual(words, test.words) {
+				t.Errorf("read %v, expected %v", words, test.words)
+			}
+		})
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	for filename, expected := range map[string]Format{"Max.hack": Hack, "Max.bin": BinaryLE, "Max.hex": IntelHex, "Max.rom": Logisim} {
+		if format, err := FormatOf(filename); err != nil || format != expected {
+			t.Errorf("FormatOf(%s) = %v, %v, expected %v", filename, format, err, expected)
+		}
+	}
+
+	if _, err := FormatOf("Max.txt"); err == nil {
+		t.Error("expected the unknown format of Max.txt")
+	}
+}
@@ -0,0 +1,112 @@
+package rom
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// intelHexWords is the number of words in the data record of the Intel HEX
+const intelHexWords = 8
+
+// logisimWords is the number of words per line of the Logisim image
+const logisimWords = 8
+
+// Write writes the words to the output in the given format.
+func Write(output io.Writer, words []uint16, format Format) error {
+	w := bufio.NewWriter(output)
+
+	switch format {
+	case Hack, ReadMemB:
+		for _, word := range words {
+			fmt.Fprintf(w, "%016b\n", word)
+		}
+	case ReadMemH:
+		for _, word := range words {
+			fmt.Fprintf(w, "%04x\n", word)
+		}
+	case BinaryLE:
+		binary.Write(w, binary.LittleEndian, words)
+	case BinaryBE:
+		binary.Write(w, binary.BigEndian, words)
+	case IntelHex:
+		writeIntelHex(w, words)
+	case Logisim:
+		writeLogisim(w, words)
+	default:
+		return fmt.Errorf("unknown format %v", format)
+	}
+
+	return w.Flush()
+}
+
+// writeIntelHex writes the data records followed by the end of file record
+//   Format: :LLAAAATT<data>CC
+// where LL is the number of data bytes, AAAA the byte address,
+// TT the record type and CC the two's complement checksum.
+func writeIntelHex(w *bufio.Writer, words []uint16) {
+	for start := 0; start < len(words); start += intelHexWords {
+		end := start + intelHexWords
+		if end > len(words) {
+			end = len(words)
+		}
+
+		address := start * 2
+		record := []byte{byte((end - start) * 2), byte(address >> 8), byte(address), 0x00}
+		for _, word := range words[start:end] {
+			record = append(record, byte(word>>8), byte(word))
+		}
+
+		writeIntelHexRecord(w, record)
+	}
+
+	writeIntelHexRecord(w, []byte{0x00, 0x00, 0x00, 0x01})
+}
+
+// writeIntelHexRecord writes the record bytes followed by the checksum
+func writeIntelHexRecord(w *bufio.Writer, record []byte) {
+	var sum byte
+
+	w.WriteByte(':')
+	for _, b := range record {
+		fmt.Fprintf(w, "%02X", b)
+		sum += b
+	}
+
+	fmt.Fprintf(w, "%02X\n", -sum)
+}
+
+// writeLogisim writes the v2.0 raw image, runs of the same word are written as count*word
+func writeLogisim(w *bufio.Writer, words []uint16) {
+	w.WriteString("v2.0 raw\n")
+
+	column := 0
+	for i := 0; i < len(words); {
+		run := 1
+		for i+run < len(words) && words[i+run] == words[i] {
+			run++
+		}
+
+		if column > 0 {
+			w.WriteByte(' ')
+		}
+
+		if run > 2 {
+			fmt.Fprintf(w, "%d*%x", run, words[i])
+			i += run
+		} else {
+			fmt.Fprintf(w, "%x", words[i])
+			i++
+		}
+
+		if column++; column == logisimWords {
+			w.WriteByte('\n')
+			column = 0
+		}
+	}
+
+	if column > 0 {
+		w.WriteByte('\n')
+	}
+}
@@ -79,6 +79,7 @@ func (e *Engine) compileSubroutineDeclarations(classVariables int) {
 		e.symbolTable.NewSubroutine()
 		e.ifCounter = 0
 		e.whileCounter = 0
+		e.vm.SetLine(e.tokenizer.Line())
 
 		subroutineType := e.tokenizer.Keyword()
 
@@ -9,6 +9,7 @@ import (
 	"github.com/ProchazkaDavid/nand2tetris/compiler/token"
 	"github.com/ProchazkaDavid/nand2tetris/compiler/tokenizer"
 	"github.com/ProchazkaDavid/nand2tetris/compiler/vm"
+	"github.com/ProchazkaDavid/nand2tetris/sourcemap"
 )
 
 var (
@@ -36,6 +37,29 @@ func NewEngine(input io.Reader, output io.StringWriter) *Engine {
 	}
 }
 
+// SourceMap returns the map of the lines of the compiled .vm file to the lines of the .jack file.
+func (e *Engine) SourceMap(filename string) sourcemap.Map { return e.vm.SourceMap(filename) }
+
+// Compile compiles the class read from the input into the output and returns the map
+// of the .vm lines to the lines of the .jack file named by the filename.
+// Errors of the compilation are returned instead of panicking.
+func Compile(input io.Reader, filename string, output io.StringWriter) (sourceMap sourcemap.Map, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			switch r := r.(type) {
+			case error:
+				err = r
+			default:
+				err = fmt.Errorf("%v", r)
+			}
+		}
+	}()
+
+	engine := NewEngine(input, output)
+	engine.CompileClass()
+	return engine.SourceMap(filename), nil
+}
+
 // compileParameterList compiles a (possibly empty) parameter list.
 // Doest not handle the enclosing "()". Returns number of parameters.
 func (e *Engine) compileParameterList() (parameters int) {
@@ -0,0 +1,70 @@
+package compilation
+
+import (
+	"strings"
+	"testing"
+)
+
+// class spans the statements over several lines and skips the lines of the comments
+const class = `/** Tests the lines
+ *  of the source map. */
+class Main {
+  static int x; // the static variable
+
+  function int f(int a) {
+    var int b;
+    let b = a +
+      2;
+    if (b > 3) {
+      do Output.printInt(b);
+    } else {
+      let x = b * 2;
+    }
+    /** the loop */ while (b < 10) {
+      let b = b + 1;
+    }
+    return b;
+  }
+}
+`
+
+func TestSourceMap(t *testing.T) {
+	// lines of the .jack file of the compiled commands, the commands following the nested
+	// statements belong to the enclosing statement
+	expected := []struct {
+		command string
+		line    int
+	}{
+		{"function Main.f 1", 6},
+		{"push argument 0", 8}, {"push constant 2", 8}, {"add", 8}, {"pop local 0", 8},
+		{"push local 0", 10}, {"push constant 3", 10}, {"gt", 10},
+		{"if-goto IF_TRUE0", 10}, {"goto IF_FALSE0", 10}, {"label IF_TRUE0", 10},
+		{"push local 0", 11}, {"call Output.printInt 1", 11}, {"pop temp 0", 11},
+		{"goto IF_END0", 10}, {"label IF_FALSE0", 10},
+		{"push local 0", 13}, {"push constant 2", 13}, {"call Math.multiply 2", 13}, {"pop static 0", 13},
+		{"label IF_END0", 10},
+		{"label WHILE_EXP0", 15}, {"push local 0", 15}, {"push constant 10", 15}, {"lt", 15},
+		{"not", 15}, {"if-goto WHILE_END0", 15},
+		{"push local 0", 16}, {"push constant 1", 16}, {"add", 16}, {"pop local 0", 16},
+		{"goto WHILE_EXP0", 15}, {"label WHILE_END0", 15},
+		{"push local 0", 18}, {"return", 18},
+	}
+
+	var output strings.Builder
+	sourceMap, err := Compile(strings.NewReader(class), "Main.jack", &output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commands := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+	if len(commands) != len(expected) || len(sourceMap) != len(expected) {
+		t.Fatalf("compiled %d commands with %d entries, expected %d", len(commands), len(sourceMap), len(expected))
+	}
+
+	for i, command := range commands {
+		source, ok := sourceMap.Lookup(i + 1)
+		if command != expected[i].command || !ok || source.Filename != "Main.jack" || source.Line != expected[i].line {
+			t.Errorf("line %d: %q from %v, expected %q from Main.jack:%d", i+1, command, source, expected[i].command, expected[i].line)
+		}
+	}
+}
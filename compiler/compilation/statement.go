@@ -10,7 +10,12 @@ import (
 // compileStatements compiles a sequence of statements.
 // Does not handle the enclosing "{}".
 func (e *Engine) compileStatements() {
+	// Commands following the statements belong to the enclosing statement
+	defer e.vm.SetLine(e.vm.Line())
+
 	for e.isOneOfKeywords(token.Let, token.If, token.While, token.Do, token.Return) {
+		e.vm.SetLine(e.tokenizer.Line())
+
 		switch e.tokenizer.Keyword() {
 		case token.Let:
 			e.compileLet()
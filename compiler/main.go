@@ -2,6 +2,7 @@ package main
 
 import (
 	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -9,20 +10,24 @@ import (
 	"strings"
 
 	"github.com/ProchazkaDavid/nand2tetris/compiler/compilation"
+	"github.com/ProchazkaDavid/nand2tetris/sourcemap"
 )
 
 func main() {
-	if len(os.Args) != 2 {
+	sourceMap := flag.Bool("map", false, "write the map of the .vm lines to the .jack lines into the .vm.map file")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
 		log.Fatalln("expected one argument - file or folder")
 	}
 
-	if err := run(os.Args[1]); err != nil {
+	if err := run(flag.Arg(0), *sourceMap); err != nil {
 		log.Fatalln(err)
 	}
 }
 
-// run compiles given file or folder
-func run(path string) error {
+// run compiles given file or folder, the sourceMap writes the source map of every file
+func run(path string, sourceMap bool) error {
 	input, err := os.Open(path)
 	if err != nil {
 		return err
@@ -56,11 +61,33 @@ func run(path string) error {
 			return errors.New("can't create the ouput file")
 		}
 
-		compilation.NewEngine(inputFile, vmOutput).CompileClass()
+		engine := compilation.NewEngine(inputFile, vmOutput)
+		engine.CompileClass()
 
 		vmOutput.Close()
 		inputFile.Close()
+
+		if sourceMap {
+			if err := writeSourceMap(vmFilename+".map", engine.SourceMap(file)); err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
 }
+
+// writeSourceMap writes the source map into the file
+func writeSourceMap(filename string, sourceMap sourcemap.Map) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("can't create the source map: %w", err)
+	}
+
+	if err := sourceMap.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
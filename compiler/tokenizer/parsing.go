@@ -54,8 +54,32 @@ func (t *Tokenizer) parseIndetifier() (string, error) {
 // parseNumber parses number.
 func (t *Tokenizer) parseNumber() (string, error) { return t.parse(isNumber) }
 
+// lineReader counts the lines of the bytes read from the reader.
+type lineReader struct {
+	*bufio.Reader
+	line int
+}
+
+// ReadByte reads a byte and counts the new line.
+func (r *lineReader) ReadByte() (byte, error) {
+	char, err := r.Reader.ReadByte()
+	if err == nil && char == '\n' {
+		r.line++
+	}
+
+	return char, err
+}
+
+// ReadString reads until the delimiter and counts the new lines.
+func (r *lineReader) ReadString(delim byte) (string, error) {
+	text, err := r.Reader.ReadString(delim)
+	r.line += strings.Count(text, "\n")
+
+	return text, err
+}
+
 // peek peeks at one byte.
-func peek(scanner *bufio.Reader) (byte, error) {
+func peek(scanner *lineReader) (byte, error) {
 	chars, err := scanner.Peek(1)
 	if err != nil {
 		return 0, err
@@ -65,12 +89,12 @@ func peek(scanner *bufio.Reader) (byte, error) {
 }
 
 // readByte reads a byte.
-func readByte(scanner *bufio.Reader) error {
+func readByte(scanner *lineReader) error {
 	_, err := scanner.ReadByte()
 	return err
 }
 
 // eatByte reads a byte and returns if the reading was successful.
-func eatByte(scanner *bufio.Reader) bool {
+func eatByte(scanner *lineReader) bool {
 	return readByte(scanner) == nil
 }
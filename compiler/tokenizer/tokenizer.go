@@ -15,12 +15,19 @@ var specialChars = []byte{' ', '\n', '\r', '\t'}
 
 // Tokenizer tokenizes .jack file, removes all white space and comments.
 type Tokenizer struct {
-	scanner *bufio.Reader
+	scanner *lineReader
 	token   string
+	// line is the line of the current token
+	line int
 }
 
 // New opens the input .jack file and gets ready to tokenize it.
-func New(file io.Reader) *Tokenizer { return &Tokenizer{bufio.NewReader(file), ""} }
+func New(file io.Reader) *Tokenizer {
+	return &Tokenizer{scanner: &lineReader{bufio.NewReader(file), 1}}
+}
+
+// Line returns the line number of the current token.
+func (t *Tokenizer) Line() int { return t.line }
 
 // HasMoreTokens returns true if there are more tokens in the input.
 func (t *Tokenizer) HasMoreTokens() bool {
@@ -85,6 +92,8 @@ func (t *Tokenizer) HasMoreTokens() bool {
 // Should be called only if HasMoreTokens() is true.
 // Initially there is no current command.
 func (t *Tokenizer) Advance() error {
+	t.line = t.scanner.line
+
 	chars, err := t.scanner.Peek(1)
 	if err != nil {
 		return err
@@ -3,15 +3,37 @@ package vm
 import (
 	"fmt"
 	"io"
+
+	"github.com/ProchazkaDavid/nand2tetris/sourcemap"
 )
 
 // Writer produces stack based commands for the VM.
 type Writer struct {
 	output io.StringWriter
+	// line is the line of the .jack file of the written commands
+	line int
+	// sources contains the line of the .jack file of every written command
+	sources []int
 }
 
 // NewWriter create a new output .vm file and prepares it for writing.
-func NewWriter(output io.StringWriter) *Writer { return &Writer{output} }
+func NewWriter(output io.StringWriter) *Writer { return &Writer{output: output} }
+
+// Line returns the line of the .jack file of the following commands.
+func (w *Writer) Line() int { return w.line }
+
+// SetLine sets the line of the .jack file of the following commands.
+func (w *Writer) SetLine(line int) { w.line = line }
+
+// SourceMap returns the map of the written lines to the lines of the .jack file.
+func (w *Writer) SourceMap(filename string) sourcemap.Map {
+	m := make(sourcemap.Map, 0, len(w.sources))
+	for i, line := range w.sources {
+		m = append(m, sourcemap.Entry{Target: i + 1, Source: sourcemap.Position{Filename: filename, Line: line}})
+	}
+
+	return m
+}
 
 // WritePush writes a VM push command.
 func (w *Writer) WritePush(segment Segment, index int) {
@@ -108,4 +130,6 @@ func (w *Writer) write(line string) {
 	if _, err := w.output.WriteString(line + "\n"); err != nil {
 		panic(fmt.Errorf("can't write to the file: %w", err))
 	}
+
+	w.sources = append(w.sources, w.line)
 }
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// operand is the ALU input after the zx and nx bits, either a constant 0 or -1,
+// or the register which may be negated bitwise
+type operand struct {
+	register string
+	constant int
+	negated  bool
+}
+
+// aluOperand returns the input of the ALU zeroed by the zero bit and negated by the negate bit
+func aluOperand(register string, zero, negate bool) operand {
+	if zero {
+		register = ""
+	}
+
+	if !negate {
+		return operand{register: register}
+	}
+
+	if register == "" {
+		return operand{constant: -1}
+	}
+
+	return operand{register: register, negated: true}
+}
+
+func (o operand) String() string {
+	switch {
+	case o.register == "":
+		return fmt.Sprint(o.constant)
+	case o.negated:
+		return "!" + o.register
+	default:
+		return o.register
+	}
+}
+
+// describeComp returns the computation of the comp bits (a, c1, c2, c3, c4, c5, c6)
+// in the notation of the mnemonics, e.g. D+A+1 or !D|!M.
+func describeComp(comp uint16) string {
+	y := "A"
+	if comp&0x40 != 0 {
+		y = "M"
+	}
+
+	bit := func(mask uint16) bool { return comp&mask != 0 }
+	x := aluOperand("D", bit(0x20), bit(0x10))
+	yOperand := aluOperand(y, bit(0x08), bit(0x04))
+
+	if bit(0x02) {
+		return describeSum(x, yOperand, bit(0x01))
+	}
+
+	return describeAnd(x, yOperand, bit(0x01))
+}
+
+// describeSum returns the sum of the operands, negated bitwise if the negate is true.
+// The sum is written as a linear combination of the registers, because !x = -x-1.
+func describeSum(x, y operand, negate bool) string {
+	coefficients := map[string]int{}
+	constant := 0
+
+	for _, o := range []operand{x, y} {
+		switch {
+		case o.register == "":
+			constant += o.constant
+		case o.negated:
+			coefficients[o.register]--
+			constant--
+		default:
+			coefficients[o.register]++
+		}
+	}
+
+	if negate {
+		for register := range coefficients {
+			coefficients[register] = -coefficients[register]
+		}
+		constant = -constant - 1
+	}
+
+	// Positive terms are written first, so D-A is preferred to -A+D
+	var positive, negative []string
+	for _, register := range []string{"D", "A", "M"} {
+		switch coefficients[register] {
+		case 1:
+			positive = append(positive, register)
+		case -1:
+			negative = append(negative, register)
+		}
+	}
+
+	var builder strings.Builder
+	for i, register := range positive {
+		if i > 0 {
+			builder.WriteString("+")
+		}
+		builder.WriteString(register)
+	}
+	for _, register := range negative {
+		builder.WriteString("-" + register)
+	}
+
+	switch {
+	case builder.Len() == 0:
+		return fmt.Sprint(constant)
+	case constant > 0:
+		fmt.Fprintf(&builder, "+%d", constant)
+	case constant < 0:
+		fmt.Fprintf(&builder, "%d", constant)
+	}
+
+	return builder.String()
+}
+
+// describeAnd returns the bitwise and of the operands, negated bitwise if the negate is true.
+// The negated and is written as the or of the negated operands.
+func describeAnd(x, y operand, negate bool) string {
+	var operands []operand
+
+	switch {
+	case x.register == "" && x.constant == 0 || y.register == "" && y.constant == 0:
+		operands = []operand{{constant: 0}}
+	case x.register == "":
+		operands = []operand{y}
+	case y.register == "":
+		operands = []operand{x}
+	default:
+		operands = []operand{x, y}
+	}
+
+	if !negate {
+		return join(operands, "&")
+	}
+
+	for i, o := range operands {
+		if o.register == "" {
+			operands[i].constant = -o.constant - 1
+		} else {
+			operands[i].negated = !o.negated
+		}
+	}
+
+	return join(operands, "|")
+}
+
+// join returns the operands separated by the operator
+func join(operands []operand, operator string) string {
+	names := make([]string, 0, len(operands))
+	for _, o := range operands {
+		names = append(names, o.String())
+	}
+
+	return strings.Join(names, operator)
+}
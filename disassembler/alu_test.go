@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bufio"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ProchazkaDavid/nand2tetris/assembler/asm"
+)
+
+func TestDescribeComp(t *testing.T) {
+	tests := []struct {
+		comp uint16
+		name string
+	}{
+		// Canonical computations
+		{0b0101010, "0"},
+		{0b0111111, "1"},
+		{0b0111010, "-1"},
+		{0b0001100, "D"},
+		{0b1110000, "M"},
+		{0b0001101, "!D"},
+		{0b0001111, "-D"},
+		{0b0011111, "D+1"},
+		{0b1110010, "M-1"},
+		{0b0000010, "D+A"},
+		{0b0010011, "D-A"},
+		{0b1000111, "M-D"},
+		{0b0000000, "D&A"},
+		{0b1010101, "D|M"},
+		// Non-canonical computations
+		{0b0111110, "-2"},
+		{0b0000011, "-D-A-1"},
+		{0b0010111, "D+A+1"},
+		{0b0110110, "-A-2"},
+		{0b1001000, "0"},
+		{0b0101100, "0"},
+		{0b0010001, "D|!A"},
+		{0b1000001, "!D|!M"},
+		{0b0110100, "!A"},
+	}
+
+	for _, test := range tests {
+		if name := describeComp(test.comp); name != test.name {
+			t.Errorf("describeComp(%07b) = %s, expected %s", test.comp, name, test.name)
+		}
+	}
+}
+
+func TestDisassembleRawComp(t *testing.T) {
+	words := []uint16{0xEF90, 0xE0E9}
+	expected := "    D=comp(0b0111110) // -2\n    AM=comp(0b0000011);JGT // -D-A-1\n"
+
+	var output strings.Builder
+	d := disassembler{output: bufio.NewWriter(&output)}
+	if err := d.disassemble(words); err != nil {
+		t.Fatal(err)
+	}
+
+	if output.String() != expected {
+		t.Errorf("disassembled to\n%s\nexpected\n%s", output.String(), expected)
+	}
+
+	reassembled, err := asm.AssembleString(output.String(), "test.asm", asm.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(reassembled.Words, words) {
+		t.Errorf("reassembled to %04X, expected %04X", reassembled.Words, words)
+	}
+}
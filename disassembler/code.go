@@ -0,0 +1,79 @@
+package main
+
+// destMnemonics maps the dest bits (d1, d2, d3) to the mnemonic.
+var destMnemonics = map[uint16]string{
+	0b000: "",
+	0b001: "M",
+	0b010: "D",
+	0b011: "MD",
+	0b100: "A",
+	0b101: "AM",
+	0b110: "AD",
+	0b111: "AMD",
+}
+
+// compMnemonics maps the comp bits (a, c1, c2, c3, c4, c5, c6) to the mnemonic.
+var compMnemonics = map[uint16]string{
+	0b0101010: "0",
+	0b0111111: "1",
+	0b0111010: "-1",
+	0b0001100: "D",
+	0b0110000: "A",
+	0b0001101: "!D",
+	0b0110001: "!A",
+	0b0001111: "-D",
+	0b0110011: "-A",
+	0b0011111: "D+1",
+	0b0110111: "A+1",
+	0b0001110: "D-1",
+	0b0110010: "A-1",
+	0b0000010: "D+A",
+	0b0010011: "D-A",
+	0b0000111: "A-D",
+	0b0000000: "D&A",
+	0b0010101: "D|A",
+	0b1110000: "M",
+	0b1110001: "!M",
+	0b1110011: "-M",
+	0b1110111: "M+1",
+	0b1110010: "M-1",
+	0b1000010: "D+M",
+	0b1010011: "D-M",
+	0b1000111: "M-D",
+	0b1000000: "D&M",
+	0b1010101: "D|M",
+}
+
+// jumpMnemonics maps the jump bits (j1, j2, j3) to the mnemonic.
+var jumpMnemonics = map[uint16]string{
+	0b000: "",
+	0b001: "JGT",
+	0b010: "JEQ",
+	0b011: "JGE",
+	0b100: "JLT",
+	0b101: "JNE",
+	0b110: "JLE",
+	0b111: "JMP",
+}
+
+// predefinedSymbols maps addresses to the predefined symbols of the assembler.
+var predefinedSymbols = map[uint16][]string{
+	0x0000: {"SP", "R0"},
+	0x0001: {"LCL", "R1"},
+	0x0002: {"ARG", "R2"},
+	0x0003: {"THIS", "R3"},
+	0x0004: {"THAT", "R4"},
+	0x0005: {"R5"},
+	0x0006: {"R6"},
+	0x0007: {"R7"},
+	0x0008: {"R8"},
+	0x0009: {"R9"},
+	0x000A: {"R10"},
+	0x000B: {"R11"},
+	0x000C: {"R12"},
+	0x000D: {"R13"},
+	0x000E: {"R14"},
+	0x000F: {"R15"},
+	0x4000: {"SCREEN"},
+	0x6000: {"KBD"},
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// isCCommand checks if the word is a C-instruction.
+func isCCommand(word uint16) bool { return word&0x8000 != 0 }
+
+// isJumpLoad checks if the A-instruction at the index is immediately followed
+// by a jumping C-instruction, so it loads the jump target.
+func isJumpLoad(program []uint16, i int) bool {
+	return i+1 < len(program) && !isCCommand(program[i]) && isCCommand(program[i+1]) && program[i+1]&0x7 != 0 &&
+		int(program[i]) <= len(program)
+}
+
+// jumpTargets returns addresses which are loaded by an A-instruction
+// immediately followed by a jumping C-instruction.
+func jumpTargets(program []uint16) map[uint16]bool {
+	targets := map[uint16]bool{}
+
+	for i, address := range program {
+		if isJumpLoad(program, i) {
+			targets[address] = true
+		}
+	}
+
+	return targets
+}
+
+// label returns the synthesized label of the ROM address.
+func label(address uint16) string { return fmt.Sprintf("L%d", address) }
+
+// disassembler translates the binary code back into the assembly.
+type disassembler struct {
+	output  *bufio.Writer
+	symbols bool
+}
+
+// disassemble writes the assembly of the program to the output.
+func (d *disassembler) disassemble(program []uint16) error {
+	targets := jumpTargets(program)
+	var invalid []string
+
+	for i, word := range program {
+		address := uint16(i)
+		if targets[address] {
+			fmt.Fprintf(d.output, "(%s)\n", label(address))
+		}
+
+		if !isCCommand(word) {
+			d.writeACommand(word, isJumpLoad(program, i))
+			continue
+		}
+
+		command, err := decodeCCommand(word)
+		if err != nil {
+			invalid = append(invalid, fmt.Sprintf("ROM[%d]: %v", address, err))
+			fmt.Fprintf(d.output, "    // %016b\n", word)
+			continue
+		}
+
+		fmt.Fprintf(d.output, "    %s\n", command)
+	}
+
+	if targets[uint16(len(program))] {
+		fmt.Fprintf(d.output, "(%s)\n", label(uint16(len(program))))
+	}
+
+	if err := d.output.Flush(); err != nil {
+		return err
+	}
+
+	if len(invalid) > 0 {
+		return fmt.Errorf("can't disassemble all instructions:\n%s", strings.Join(invalid, "\n"))
+	}
+
+	return nil
+}
+
+// writeACommand writes the A-instruction, the jump target loaded for the following jump
+// is replaced by its label and predefined symbols are optionally annotated. Data loads
+// keep the number, even if it equals the address of some label.
+func (d *disassembler) writeACommand(address uint16, jump bool) {
+	if jump {
+		fmt.Fprintf(d.output, "    @%s\n", label(address))
+		return
+	}
+
+	if symbols, ok := predefinedSymbols[address]; ok && d.symbols {
+		fmt.Fprintf(d.output, "    @%d // %s\n", address, strings.Join(symbols, ", "))
+		return
+	}
+
+	fmt.Fprintf(d.output, "    @%d\n", address)
+}
+
+// decodeCCommand returns the dest=comp;jump representation of the C-instruction.
+func decodeCCommand(word uint16) (string, error) {
+	if word&0xE000 != 0xE000 {
+		return "", fmt.Errorf("C-instruction %016b doesn't start with 111", word)
+	}
+
+	// Non-canonical comp bits are written raw and named by a comment
+	bits := word >> 6 & 0x7F
+	comp, ok := compMnemonics[bits]
+	if !ok {
+		comp = fmt.Sprintf("comp(0b%07b)", bits)
+	}
+
+	command := comp
+	if dest := destMnemonics[word>>3&0x7]; dest != "" {
+		command = dest + "=" + command
+	}
+	if jump := jumpMnemonics[word&0x7]; jump != "" {
+		command += ";" + jump
+	}
+
+	if !ok {
+		command += " // " + describeComp(bits)
+	}
+
+	return command, nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bufio"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ProchazkaDavid/nand2tetris/assembler/asm"
+)
+
+func TestDisassembleJumpTargets(t *testing.T) {
+	source := `    @L2
+    0;JMP
+(L2)
+    @2
+    M=D
+    @L2
+    D;JGT
+`
+
+	program, err := asm.AssembleString(source, "test.asm", asm.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var output strings.Builder
+	d := disassembler{output: bufio.NewWriter(&output)}
+	if err := d.disassemble(program.Words); err != nil {
+		t.Fatal(err)
+	}
+
+	if output.String() != source {
+		t.Errorf("disassembled to\n%s\nexpected\n%s", output.String(), source)
+	}
+
+	reassembled, err := asm.AssembleString(output.String(), "test.asm", asm.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(reassembled.Words, program.Words) {
+		t.Errorf("reassembled to %v, expected %v", reassembled.Words, program.Words)
+	}
+}
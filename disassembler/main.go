@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/ProchazkaDavid/nand2tetris/assembler/rom"
+)
+
+func main() {
+	symbols := flag.Bool("symbols", false, "annotate addresses of predefined symbols (SP, LCL, SCREEN, ...)")
+	format := flag.String("format", "", "format of the program: "+strings.Join(rom.Names(), ", ")+" (default by the file extension)")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		log.Fatalln("expected single program file")
+	}
+
+	programFormat, err := rom.FormatOf(flag.Arg(0))
+	if *format != "" {
+		programFormat, err = rom.ParseFormat(*format)
+	}
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if err := run(flag.Arg(0), programFormat, *symbols); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// run disassembles the given program file and writes the assembly to the standard output
+func run(filename string, format rom.Format, symbols bool) error {
+	program, err := rom.ReadFile(filename, format)
+	if err != nil {
+		return fmt.Errorf("can't read %s: %w", filename, err)
+	}
+
+	d := disassembler{output: bufio.NewWriter(os.Stdout), symbols: symbols}
+	return d.disassemble(program)
+}
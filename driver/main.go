@@ -0,0 +1,428 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ProchazkaDavid/nand2tetris/assembler/asm"
+	"github.com/ProchazkaDavid/nand2tetris/assembler/rom"
+	"github.com/ProchazkaDavid/nand2tetris/compiler/compilation"
+	"github.com/ProchazkaDavid/nand2tetris/sourcemap"
+	"github.com/ProchazkaDavid/nand2tetris/vm/command"
+	"github.com/ProchazkaDavid/nand2tetris/vm/translator"
+	"github.com/ProchazkaDavid/nand2tetris/vm/verifier"
+)
+
+// options configures the build
+type options struct {
+	buildDir  string
+	keep      bool
+	optimize  bool
+	compact   bool
+	eliminate bool
+	format    rom.Format
+	ram       asm.RAMCheck
+	usage     bool
+	sourceMap bool
+}
+
+func main() {
+	buildDir := flag.String("o", "build", "build directory of the output")
+	keep := flag.Bool("keep", false, "keep the intermediate .vm and .asm files in the build directory")
+	optimize := flag.Bool("O", false, "run the peephole optimizer of the assembler")
+	compact := flag.Bool("compact", false, "share the call, return and comparison routines of the VM code")
+	eliminate := flag.Bool("eliminate", false, "build only functions reachable from Sys.init and report the removed functions")
+	format := flag.String("format", "hack", "output format: "+strings.Join(rom.Names(), ", "))
+	ramCheck := flag.String("ram", "warn", "report variables outside of the static region 16-255: warn, error or ignore")
+	sourceMap := flag.Bool("map", false, "write the map of ROM addresses to the .jack lines into the .rom.map file")
+	usage := flag.Bool("usage", false, "print the ROM and RAM usage of the program with the size of every function")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		log.Fatalln("expected one argument - folder")
+	}
+
+	outputFormat, err := rom.ParseFormat(*format)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	check, err := asm.ParseRAMCheck(*ramCheck)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if err := run(flag.Arg(0), options{*buildDir, *keep, *optimize, *compact, *eliminate, outputFormat, check, *usage, *sourceMap}); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// stageError reports all errors of the failing stage of the pipeline
+type stageError struct {
+	stage  string
+	errors []error
+}
+
+func (e *stageError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s failed:", e.stage)
+	for _, err := range e.errors {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(&b, "\n  %s", line)
+		}
+	}
+
+	return b.String()
+}
+
+// vmFile is the VM code of the class, either compiled or read from the .vm file
+type vmFile struct {
+	filename string
+	code     []byte
+	// sourceMap maps the lines of the compiled code to the .jack file, nil for the read .vm files
+	sourceMap sourcemap.Map
+}
+
+// run builds the .jack, .vm and .asm files in the folder into the single program
+func run(path string, opts options) error {
+	sources, err := findSources(path)
+	if err != nil {
+		return err
+	}
+
+	if len(sources[".jack"]) == 0 && len(sources[".vm"]) == 0 {
+		return fmt.Errorf("no .jack or .vm files in %s", path)
+	}
+
+	if err := os.MkdirAll(opts.buildDir, 0755); err != nil {
+		return fmt.Errorf("can't create the build directory: %w", err)
+	}
+
+	vmFiles, err := compile(sources[".jack"], sources[".vm"])
+	if err != nil {
+		return err
+	}
+
+	if err := verify(vmFiles, sources[".asm"]); err != nil {
+		return err
+	}
+
+	name := filepath.Base(filepath.Clean(path))
+	asmFilename := filepath.Join(opts.buildDir, name+".asm")
+
+	program, asmMap, err := translate(vmFiles, sources[".asm"], asmFilename, opts)
+	if err != nil {
+		return err
+	}
+
+	// Source maps of the stages keyed by their generated files
+	sourceMaps := map[string]sourcemap.Map{asmFilename: asmMap}
+	for _, file := range vmFiles {
+		if file.sourceMap != nil {
+			sourceMaps[file.filename] = file.sourceMap
+		}
+	}
+
+	if opts.keep {
+		for _, file := range vmFiles {
+			if err := os.WriteFile(filepath.Join(opts.buildDir, filepath.Base(file.filename)), file.code, 0644); err != nil {
+				return err
+			}
+		}
+
+		if err := os.WriteFile(asmFilename, program, 0644); err != nil {
+			return err
+		}
+
+		if err := writeSourceMaps(sourceMaps, opts); err != nil {
+			return err
+		}
+	}
+
+	assembled, err := asm.Assemble(bytes.NewReader(program), asmFilename, asm.Options{Optimize: opts.optimize, RAM: opts.ram})
+	if err != nil {
+		return &stageError{"assemble", []error{err}}
+	}
+
+	if opts.optimize {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", name, assembled.Optimized)
+	}
+
+	for _, warning := range assembled.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+
+	if opts.usage {
+		if err := assembled.Usage().WriteUsage(os.Stdout); err != nil {
+			return err
+		}
+	}
+
+	if opts.sourceMap {
+		if err := writeFile(filepath.Join(opts.buildDir, name+".rom.map"), assembled.SourceMap().Compose(sourceMaps).Write); err != nil {
+			return err
+		}
+	}
+
+	return writeFile(filepath.Join(opts.buildDir, name+opts.format.Extension()), func(w io.Writer) error {
+		return assembled.WriteFormat(w, opts.format)
+	})
+}
+
+// writeSourceMaps writes the source maps of the kept files into the build directory if requested
+func writeSourceMaps(sourceMaps map[string]sourcemap.Map, opts options) error {
+	if !opts.sourceMap {
+		return nil
+	}
+
+	for filename, sourceMap := range sourceMaps {
+		if err := writeFile(filepath.Join(opts.buildDir, filepath.Base(filename)+".map"), sourceMap.Write); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// findSources returns sorted .jack, .vm and .asm files in the folder by their extension
+func findSources(path string) (map[string][]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return nil, fmt.Errorf("expected folder, got %s", path)
+	}
+
+	sources := map[string][]string{}
+	for _, ext := range []string{".jack", ".vm", ".asm"} {
+		files, err := filepath.Glob(filepath.Join(path, "*"+ext))
+		if err != nil {
+			return nil, fmt.Errorf("can't get input files: %w", err)
+		}
+
+		sort.Strings(files)
+		sources[ext] = files
+	}
+
+	return sources, nil
+}
+
+// compile compiles the .jack files into the VM code in memory and reads the other .vm files.
+// The .vm files with the .jack counterpart are the outputs of the previous compilation
+// and are skipped. All classes are compiled before the failure is reported.
+func compile(jackFiles, vmFilenames []string) ([]vmFile, error) {
+	var files []vmFile
+	var errors []error
+	compiled := map[string]bool{}
+
+	for _, filename := range jackFiles {
+		vmFilename := strings.TrimSuffix(filename, ".jack") + ".vm"
+		compiled[vmFilename] = true
+
+		var code bytes.Buffer
+		sourceMap, err := compileFile(filename, &code)
+		if err != nil {
+			errors = append(errors, fmt.Errorf("%s: %w", filename, err))
+			continue
+		}
+
+		files = append(files, vmFile{vmFilename, code.Bytes(), sourceMap})
+	}
+
+	if len(errors) > 0 {
+		return nil, &stageError{"compile", errors}
+	}
+
+	for _, filename := range vmFilenames {
+		if compiled[filename] {
+			continue
+		}
+
+		code, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, vmFile{filename, code, nil})
+	}
+
+	return files, nil
+}
+
+// compileFile compiles the .jack file into the output and returns its source map
+func compileFile(filename string, output io.StringWriter) (sourcemap.Map, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return compilation.Compile(f, filename, output)
+}
+
+// verify runs the VM verifier over the whole program before the translation, so calls of misspelled
+// or missing functions don't silently become variables of the assembly. Labels of the .asm files
+// are external functions of the program, functions of the OS have to be defined by its .vm files.
+func verify(vmFiles []vmFile, asmFiles []string) error {
+	external := map[string]bool{}
+	for _, filename := range asmFiles {
+		program, err := asm.AssembleFile(filename, asm.Options{RAM: asm.RAMIgnore})
+		if err != nil {
+			return &stageError{"assemble", []error{err}}
+		}
+
+		for name, symbol := range program.Symbols {
+			if symbol.Kind == asm.Label {
+				external[name] = true
+			}
+		}
+	}
+
+	var errors command.ErrorList
+	files := make([]verifier.File, 0, len(vmFiles))
+	for _, file := range vmFiles {
+		commands, err := command.Parse(bytes.NewReader(file.code), file.filename)
+		if list, ok := err.(command.ErrorList); ok {
+			errors = append(errors, list...)
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		files = append(files, verifier.File{Filename: file.filename, Commands: commands})
+	}
+
+	if len(errors) == 0 {
+		errors = verifier.VerifyExternal(files, external)
+	}
+
+	stageErrors := make([]error, 0, len(errors)+2)
+	for _, err := range errors {
+		stageErrors = append(stageErrors, err)
+	}
+
+	// The bootstrap code calls Sys.init, which isn't called by the VM code
+	defined := definedFunctions(files, external)
+	if len(stageErrors) == 0 && !defined[entryFunction] {
+		stageErrors = append(stageErrors, fmt.Errorf("function %s called by the bootstrap code isn't defined", entryFunction))
+	}
+
+	if len(stageErrors) == 0 {
+		return nil
+	}
+
+	if missing := missingOSFunctions(files, defined); len(missing) > 0 {
+		stageErrors = append(stageErrors, fmt.Errorf("the OS functions %s aren't defined, add the .vm files of the OS into the folder",
+			strings.Join(missing, ", ")))
+	}
+
+	return &stageError{"verify", stageErrors}
+}
+
+// definedFunctions returns the functions of the VM code and the external functions
+func definedFunctions(files []verifier.File, external map[string]bool) map[string]bool {
+	defined := map[string]bool{}
+	for name := range external {
+		defined[name] = true
+	}
+
+	for _, file := range files {
+		for _, cmd := range file.Commands {
+			if cmd.Type == command.Function {
+				defined[cmd.First] = true
+			}
+		}
+	}
+
+	return defined
+}
+
+// translate translates the VM code into the assembly with the bootstrap code, reports
+// the removed functions and returns the source map of the assembly. The .asm files are included
+// at the end of the program relative to the asmFilename, so their labels can be called from the VM code.
+func translate(vmFiles []vmFile, asmFiles []string, asmFilename string, opts options) ([]byte, sourcemap.Map, error) {
+	var program bytes.Buffer
+
+	vmTranslator := translator.New(&program, asmFilename)
+	vmTranslator.SetCompact(opts.compact)
+	vmTranslator.SetEliminate(opts.eliminate)
+	if err := vmTranslator.WriteInit(); err != nil {
+		return nil, nil, err
+	}
+
+	files := make([]translator.File, 0, len(vmFiles))
+	for _, file := range vmFiles {
+		files = append(files, translator.File{Filename: file.filename, Code: file.code})
+	}
+
+	if err := vmTranslator.TranslateFiles(files); err != nil {
+		return nil, nil, &stageError{"translate", []error{err}}
+	}
+
+	if opts.eliminate {
+		removed := vmTranslator.Removed()
+		fmt.Fprintf(os.Stderr, "removed %d functions\n", len(removed))
+		for _, function := range removed {
+			fmt.Fprintf(os.Stderr, "  %s\n", function)
+		}
+	}
+
+	if err := vmTranslator.Finish(); err != nil {
+		return nil, nil, err
+	}
+
+	for _, filename := range asmFiles {
+		included, err := includePath(filename, filepath.Dir(asmFilename))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		fmt.Fprintf(&program, ".include %q\n", included)
+	}
+
+	return program.Bytes(), vmTranslator.SourceMap(), nil
+}
+
+// includePath returns the path of the file relative to the dir
+func includePath(filename, dir string) (string, error) {
+	absFilename, err := filepath.Abs(filename)
+	if err != nil {
+		return "", err
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	relative, err := filepath.Rel(absDir, absFilename)
+	if err != nil {
+		return "", fmt.Errorf("can't include %s: %w", filename, err)
+	}
+
+	return filepath.ToSlash(relative), nil
+}
+
+// writeFile creates the file and writes its content by the write
+func writeFile(filename string, write func(io.Writer) error) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("can't create the output file: %w", err)
+	}
+
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
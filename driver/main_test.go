@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ProchazkaDavid/nand2tetris/assembler/asm"
+)
+
+// stubOS defines the OS functions called by the tested programs
+var stubOS = map[string]string{
+	"Sys.vm":    "function Sys.init 0\ncall Main.main 0\npop temp 0\nlabel END\ngoto END\n",
+	"Math.vm":   "function Math.multiply 0\npush argument 0\nreturn\n",
+	"Output.vm": "function Output.printInt 0\npush constant 0\nreturn\n",
+}
+
+// withOS returns the files with the stubs of the OS
+func withOS(files map[string]string) map[string]string {
+	for name, code := range stubOS {
+		files[name] = code
+	}
+
+	return files
+}
+
+func TestRunVerifiesCalls(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		// err is the expected part of the error, the build succeeds if empty
+		err string
+	}{
+		{
+			name: "OS functions",
+			files: withOS(map[string]string{
+				"Main.jack": "class Main { function void main() { do Output.printInt(Math.multiply(6, 7)); return; } }",
+			}),
+		},
+		{
+			name: "missing OS",
+			files: map[string]string{
+				"Main.jack": "class Main { function void main() { do Output.printInt(Math.multiply(6, 7)); return; } }",
+			},
+			err: "the OS functions Math.multiply, Output.printInt, Sys.init aren't defined, add the .vm files of the OS into the folder",
+		},
+		{
+			name: "missing Sys.init",
+			files: map[string]string{
+				"Main.vm": "function Main.main 0\npush constant 0\nreturn\n",
+			},
+			err: "verify failed:\n  function Sys.init called by the bootstrap code isn't defined",
+		},
+		{
+			name: "misspelled function",
+			files: withOS(map[string]string{
+				"Main.jack": "class Main {\nfunction void main() {\ndo Output.printIn(42);\nreturn;\n}\n}",
+			}),
+			err: "verify failed:\n  " + filepath.Join("{dir}", "Main.vm") + ":3: function Output.printIn isn't defined",
+		},
+		{
+			name: "missing method",
+			files: withOS(map[string]string{
+				"Main.jack": "class Main { function void main() { do Main.helper(); return; } }",
+			}),
+			err: "function Main.helper isn't defined",
+		},
+		{
+			name: "label of the assembly",
+			files: withOS(map[string]string{
+				"Main.jack": "class Main { function void main() { do Fast.run(1); return; } }",
+				"fast.asm":  "(Fast.run)\n@SP\nA=M\n0;JMP\n",
+			}),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range test.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := run(dir, options{buildDir: filepath.Join(dir, "build"), ram: asm.RAMIgnore})
+
+			switch expected := strings.ReplaceAll(test.err, "{dir}", dir); {
+			case expected == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case expected != "" && (err == nil || !strings.Contains(err.Error(), expected)):
+				t.Errorf("got error %v, expected %q", err, expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/ProchazkaDavid/nand2tetris/vm/command"
+	"github.com/ProchazkaDavid/nand2tetris/vm/verifier"
+)
+
+// entryFunction is the function called by the bootstrap code
+const entryFunction = "Sys.init"
+
+// osFunctions contains the functions of the Jack OS API. The OS isn't linked by the driver,
+// so the folder has to contain its .vm files, the list only explains the missing functions.
+var osFunctions = []string{
+	"Math.init", "Math.abs", "Math.multiply", "Math.divide", "Math.min", "Math.max", "Math.sqrt",
+	"String.new", "String.dispose", "String.length", "String.charAt", "String.setCharAt", "String.appendChar",
+	"String.eraseLastChar", "String.intValue", "String.setInt", "String.backSpace", "String.doubleQuote", "String.newLine",
+	"Array.new", "Array.dispose",
+	"Output.init", "Output.moveCursor", "Output.printChar", "Output.printString", "Output.printInt", "Output.println",
+	"Output.backSpace",
+	"Screen.init", "Screen.clearScreen", "Screen.setColor", "Screen.drawPixel", "Screen.drawLine", "Screen.drawRectangle",
+	"Screen.drawCircle",
+	"Keyboard.init", "Keyboard.keyPressed", "Keyboard.readChar", "Keyboard.readLine", "Keyboard.readInt",
+	"Memory.init", "Memory.peek", "Memory.poke", "Memory.alloc", "Memory.deAlloc",
+	"Sys.init", "Sys.halt", "Sys.error", "Sys.wait",
+}
+
+// missingOSFunctions returns the sorted OS functions, which are called by the files or the bootstrap code,
+// but aren't defined
+func missingOSFunctions(files []verifier.File, defined map[string]bool) []string {
+	called := map[string]bool{entryFunction: true}
+	for _, file := range files {
+		for _, cmd := range file.Commands {
+			if cmd.Type == command.Call {
+				called[cmd.First] = true
+			}
+		}
+	}
+
+	var missing []string
+	for _, function := range osFunctions {
+		if called[function] && !defined[function] {
+			missing = append(missing, function)
+		}
+	}
+	sort.Strings(missing)
+
+	return missing
+}
@@ -0,0 +1,234 @@
+package computer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ProchazkaDavid/nand2tetris/assembler/rom"
+)
+
+// Memory layout of the Hack computer
+const (
+	// ROMSize is the number of instructions the instruction memory can hold
+	ROMSize = 0x8000
+	// RAMSize is the size of the general purpose data memory
+	RAMSize = 0x4000
+	// Screen is the base address of the screen memory map
+	Screen = 0x4000
+	// ScreenSize is the number of words of the screen memory map
+	ScreenSize = 0x2000
+	// Keyboard is the address of the keyboard memory map
+	Keyboard = 0x6000
+)
+
+var (
+	errROMOverflow   = fmt.Errorf("program doesn't fit into the %d words of the ROM", ROMSize)
+	errInvalidAccess = errors.New("invalid memory access")
+)
+
+// Computer represents the Hack computer - CPU with the A, D and PC registers,
+// the instruction memory (ROM) and the data memory (RAM) including
+// the screen and keyboard memory maps.
+type Computer struct {
+	A  uint16
+	D  uint16
+	PC uint16
+
+	ROM [ROMSize]uint16
+	// RAM contains the data memory, the screen memory map and the keyboard word
+	RAM [Keyboard + 1]uint16
+
+	// programSize is the number of loaded instructions
+	programSize int
+	// halted is set once the computer reaches a halt loop or the end of the program
+	halted bool
+}
+
+// New creates a new computer with empty memory.
+func New() *Computer { return &Computer{} }
+
+// Load loads the .hack program from the input into the ROM and resets the computer.
+// Every line of the input contains one instruction written as 16 binary digits.
+func (c *Computer) Load(input io.Reader) error {
+	words, err := rom.Read(input, rom.Hack)
+	if err != nil {
+		return err
+	}
+
+	return c.LoadWords(words)
+}
+
+// LoadWords loads the program into the ROM and resets the computer.
+func (c *Computer) LoadWords(words []uint16) error {
+	if len(words) > ROMSize {
+		return errROMOverflow
+	}
+
+	copy(c.ROM[:], words)
+	for i := len(words); i < ROMSize; i++ {
+		c.ROM[i] = 0
+	}
+
+	c.programSize = len(words)
+	c.Reset()
+
+	return nil
+}
+
+// ProgramSize returns the number of instructions of the loaded program.
+func (c *Computer) ProgramSize() int { return c.programSize }
+
+// Reset sets the registers to zero, so the execution starts again from the first instruction.
+// The content of the RAM is preserved.
+func (c *Computer) Reset() {
+	c.A, c.D, c.PC = 0, 0, 0
+	c.halted = c.programSize == 0
+}
+
+// Halted returns true if the computer reached a halt loop or the end of the program.
+func (c *Computer) Halted() bool { return c.halted }
+
+// Run executes at most cycles instructions. The execution stops earlier
+// if the computer halts. Returns number of executed instructions.
+func (c *Computer) Run(cycles int) (int, error) {
+	executed := 0
+
+	for ; executed < cycles && !c.halted; executed++ {
+		if err := c.Step(); err != nil {
+			return executed, err
+		}
+	}
+
+	return executed, nil
+}
+
+// Step executes a single instruction. Does nothing if the computer is halted.
+func (c *Computer) Step() error {
+	if c.halted {
+		return nil
+	}
+
+	instruction := c.ROM[c.PC]
+
+	// A-instruction
+	if instruction&0x8000 == 0 {
+		c.A = instruction
+		c.jump(c.PC + 1)
+		return nil
+	}
+
+	return c.execute(instruction)
+}
+
+// jump sets the PC to the address and halts the computer
+// if the address is outside of the loaded program
+func (c *Computer) jump(address uint16) {
+	c.PC = address
+	if int(c.PC) >= c.programSize {
+		c.halted = true
+	}
+}
+
+// execute executes the C-instruction
+//   Format: 111a cccc ccdd djjj
+func (c *Computer) execute(instruction uint16) error {
+	y := c.A
+	if instruction&0x1000 != 0 {
+		m, err := c.read(c.A)
+		if err != nil {
+			return err
+		}
+		y = m
+	}
+
+	out := compute(instruction>>6&0x3F, c.D, y)
+
+	// The address of M is given by the value of A before the instruction
+	address := c.A
+
+	if instruction&0x08 != 0 {
+		if err := c.write(address, out); err != nil {
+			return err
+		}
+	}
+	if instruction&0x20 != 0 {
+		c.A = out
+	}
+	if instruction&0x10 != 0 {
+		c.D = out
+	}
+
+	if !jumps(instruction&0x07, out) {
+		c.jump(c.PC + 1)
+		return nil
+	}
+
+	// A jump without destination to the @target instruction that loads its own
+	// address (@END, 0;JMP) is an infinite loop which ends the program
+	if instruction&0x38 == 0 && c.PC > 0 && address == c.PC-1 && c.ROM[address] == address {
+		c.halted = true
+	}
+
+	c.jump(address)
+	return nil
+}
+
+// compute returns the ALU output of the comp bits (zx, nx, zy, ny, f, no)
+// where x is the D register and y is either the A register or the M value.
+func compute(comp, x, y uint16) uint16 {
+	if comp&0x20 != 0 {
+		x = 0
+	}
+	if comp&0x10 != 0 {
+		x = ^x
+	}
+	if comp&0x08 != 0 {
+		y = 0
+	}
+	if comp&0x04 != 0 {
+		y = ^y
+	}
+
+	out := x & y
+	if comp&0x02 != 0 {
+		out = x + y
+	}
+
+	if comp&0x01 != 0 {
+		out = ^out
+	}
+
+	return out
+}
+
+// jumps returns true if the jump bits (j1, j2, j3) are satisfied by the ALU output
+func jumps(jump, out uint16) bool {
+	value := int16(out)
+
+	return jump&0x04 != 0 && value < 0 ||
+		jump&0x02 != 0 && value == 0 ||
+		jump&0x01 != 0 && value > 0
+}
+
+// read returns the content of the memory at the address
+func (c *Computer) read(address uint16) (uint16, error) {
+	if address > Keyboard {
+		return 0, fmt.Errorf("%w: reading from %d at ROM[%d]", errInvalidAccess, address, c.PC)
+	}
+
+	return c.RAM[address], nil
+}
+
+// write writes the value into the memory at the address
+func (c *Computer) write(address, value uint16) error {
+	if address > Keyboard {
+		return fmt.Errorf("%w: writing to %d at ROM[%d]", errInvalidAccess, address, c.PC)
+	}
+
+	c.RAM[address] = value
+	return nil
+}
+
+// SetKey sets the currently pressed key, 0 represents no key.
+func (c *Computer) SetKey(key uint16) { c.RAM[Keyboard] = key }
@@ -0,0 +1,224 @@
+package computer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ProchazkaDavid/nand2tetris/assembler/asm"
+)
+
+// load assembles the program and loads it into a new computer
+func load(t *testing.T, source string) *Computer {
+	t.Helper()
+
+	program, err := asm.AssembleString(source, "Test.asm", asm.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := New()
+	if err := c.LoadWords(program.Words); err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+func TestCompute(t *testing.T) {
+	const x, y = 7, 3
+
+	tests := []struct {
+		mnemonic string
+		comp     uint16
+		out      int16
+	}{
+		{"0", 0x2A, 0},
+		{"1", 0x3F, 1},
+		{"-1", 0x3A, -1},
+		{"D", 0x0C, x},
+		{"A", 0x30, y},
+		{"!D", 0x0D, ^x},
+		{"!A", 0x31, ^y},
+		{"-D", 0x0F, -x},
+		{"-A", 0x33, -y},
+		{"D+1", 0x1F, x + 1},
+		{"A+1", 0x37, y + 1},
+		{"D-1", 0x0E, x - 1},
+		{"A-1", 0x32, y - 1},
+		{"D+A", 0x02, x + y},
+		{"D-A", 0x13, x - y},
+		{"A-D", 0x07, y - x},
+		{"D&A", 0x00, x & y},
+		{"D|A", 0x15, x | y},
+	}
+
+	for _, test := range tests {
+		if out := int16(compute(test.comp, x, y)); out != test.out {
+			t.Errorf("%s = %d, expected %d", test.mnemonic, out, test.out)
+		}
+	}
+}
+
+func TestJumps(t *testing.T) {
+	mnemonics := []string{"", "JGT", "JEQ", "JGE", "JLT", "JNE", "JLE", "JMP"}
+	expected := map[int16]string{-1: "JLT JNE JLE JMP", 0: "JEQ JGE JLE JMP", 1: "JGT JGE JNE JMP"}
+
+	for out, jumping := range expected {
+		var taken string
+		for jump, mnemonic := range mnemonics {
+			if jumps(uint16(jump), uint16(out)) {
+				taken += " " + mnemonic
+			}
+		}
+
+		if taken != " "+jumping {
+			t.Errorf("output %d jumps by%s, expected %s", out, taken, jumping)
+		}
+	}
+}
+
+// maxProgram computes RAM[2] = max(RAM[0], RAM[1])
+const maxProgram = `@R0
+D=M
+@R1
+D=D-M
+@FIRST
+D;JGT
+@R1
+D=M
+@STORE
+0;JMP
+(FIRST)
+@R0
+D=M
+(STORE)
+@R2
+M=D
+(END)
+@END
+0;JMP
+`
+
+// multProgram computes RAM[2] = RAM[0] * RAM[1]
+const multProgram = `@R2
+M=0
+(LOOP)
+@R1
+D=M
+@END
+D;JLE
+@R0
+D=M
+@R2
+M=D+M
+@R1
+M=M-1
+@LOOP
+0;JMP
+(END)
+@END
+0;JMP
+`
+
+func TestPrograms(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		r0, r1 int16
+		r2     int16
+	}{
+		{"max first", maxProgram, 15, 3, 15},
+		{"max second", maxProgram, -4, 12, 12},
+		{"max negative", maxProgram, -4, -12, -4},
+		{"mult", multProgram, 6, 7, 42},
+		{"mult zero", multProgram, 6, 0, 0},
+		{"mult negative", multProgram, -3, 5, -15},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := load(t, test.source)
+			c.RAM[0], c.RAM[1] = uint16(test.r0), uint16(test.r1)
+
+			if _, err := c.Run(1000); err != nil {
+				t.Fatal(err)
+			}
+
+			if !c.Halted() {
+				t.Fatal("the program didn't halt")
+			}
+
+			if int16(c.RAM[2]) != test.r2 {
+				t.Errorf("RAM[2] = %d, expected %d", int16(c.RAM[2]), test.r2)
+			}
+		})
+	}
+}
+
+func TestHalt(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		cycles int
+		halted bool
+	}{
+		{"halt loop", "@5\nD=A\n(END)\n@END\n0;JMP\n", 4, true},
+		{"end of the program", "@5\nD=A\n", 2, true},
+		{"conditional halt loop", "D=0\n(END)\n@END\nD;JEQ\n", 3, true},
+		{"loop with destination", "@5\n(LOOP)\n@LOOP\nD=D+1;JMP\n", 100, false},
+		{"longer loop", "(LOOP)\n@LOOP\nD=D+1\n0;JMP\n", 100, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := load(t, test.source)
+
+			executed, err := c.Run(100)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if c.Halted() != test.halted || executed != test.cycles {
+				t.Errorf("halted %v after %d instructions, expected %v after %d", c.Halted(), executed, test.halted, test.cycles)
+			}
+		})
+	}
+}
+
+func TestMemoryAccess(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		err    bool
+	}{
+		{"read keyboard", "@KBD\nD=M\n", false},
+		{"write screen", "@SCREEN\nM=-1\n", false},
+		{"read after keyboard", "@24577\nD=M\n", true},
+		{"write after keyboard", "@32767\nM=1\n", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := load(t, test.source)
+
+			_, err := c.Run(10)
+			if test.err != errors.Is(err, errInvalidAccess) {
+				t.Errorf("got error %v, expected the invalid access %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestLoadWords(t *testing.T) {
+	c := New()
+	if err := c.LoadWords(make([]uint16, ROMSize+1)); err != errROMOverflow {
+		t.Errorf("got error %v, expected %v", err, errROMOverflow)
+	}
+
+	if err := c.LoadWords([]uint16{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if c.ProgramSize() != 2 || c.ROM[2] != 0 || c.Halted() {
+		t.Errorf("loaded %d words, expected 2 words and the running computer", c.ProgramSize())
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ProchazkaDavid/nand2tetris/assembler/asm"
+)
+
+// debugInfo contains names of the addresses read from the .sym and .lst files of the assembler
+type debugInfo struct {
+	// variables maps RAM addresses to the names of the variables
+	variables map[uint16][]string
+	// labels maps ROM addresses to the names of the labels
+	labels map[uint16][]string
+	// source maps ROM addresses to the position and text of the source line
+	source map[uint16]string
+}
+
+// readDebugInfo reads the given .sym and .lst files, empty filename is skipped
+func readDebugInfo(symbolsFilename, listingFilename string) (*debugInfo, error) {
+	info := &debugInfo{
+		variables: map[uint16][]string{},
+		labels:    map[uint16][]string{},
+		source:    map[uint16]string{},
+	}
+
+	if symbolsFilename != "" {
+		f, err := os.Open(symbolsFilename)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		table, err := asm.ReadSymbols(f)
+		if err != nil {
+			return nil, fmt.Errorf("can't read %s: %w", symbolsFilename, err)
+		}
+
+		for name, symbol := range table {
+			switch symbol.Kind {
+			case asm.Variable:
+				info.variables[symbol.Address] = append(info.variables[symbol.Address], name)
+			case asm.Label:
+				info.labels[symbol.Address] = append(info.labels[symbol.Address], name)
+			}
+		}
+	}
+
+	if listingFilename != "" {
+		f, err := os.Open(listingFilename)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		listing, err := asm.ReadListing(f)
+		if err != nil {
+			return nil, fmt.Errorf("can't read %s: %w", listingFilename, err)
+		}
+
+		for _, line := range listing {
+			if line.Instruction {
+				info.source[line.Address] = fmt.Sprintf("%s %s", line.Pos, line.Text)
+			}
+		}
+	}
+
+	return info, nil
+}
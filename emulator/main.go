@@ -0,0 +1,145 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/ProchazkaDavid/nand2tetris/assembler/rom"
+	"github.com/ProchazkaDavid/nand2tetris/emulator/computer"
+)
+
+// ramValues collects the initial RAM values given as address=value
+type ramValues map[uint16]uint16
+
+func (r ramValues) String() string { return fmt.Sprint(map[uint16]uint16(r)) }
+
+func (r ramValues) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("expected address=value, got %q", value)
+	}
+
+	address, err := strconv.ParseUint(parts[0], 0, 16)
+	if err != nil || address > computer.Keyboard {
+		return fmt.Errorf("invalid address %q", parts[0])
+	}
+
+	word, err := strconv.ParseInt(parts[1], 0, 32)
+	if err != nil || word < -0x8000 || word > 0xFFFF {
+		return fmt.Errorf("invalid value %q", parts[1])
+	}
+
+	r[uint16(address)] = uint16(word)
+	return nil
+}
+
+func main() {
+	cycles := flag.Int("cycles", 1000000, "maximum number of executed instructions")
+	key := flag.Int("key", 0, "keyboard code of the pressed key")
+	ram := ramValues{}
+	flag.Var(ram, "ram", "initial RAM value as address=value, can be repeated")
+	symbols := flag.String("symbols", "", "the .sym file of the assembler used to name variables and labels")
+	listing := flag.String("list", "", "the .lst file of the assembler used to show source lines")
+	format := flag.String("format", "", "format of the program: "+strings.Join(rom.Names(), ", ")+" (default by the file extension)")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		log.Fatalln("expected single program file")
+	}
+
+	programFormat, err := programFormat(flag.Arg(0), *format)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	info, err := readDebugInfo(*symbols, *listing)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if err := run(flag.Arg(0), programFormat, *cycles, uint16(*key), ram, info); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// programFormat returns the format given by its name, or by the extension of the file if the name is empty
+func programFormat(filename, name string) (rom.Format, error) {
+	if name == "" {
+		return rom.FormatOf(filename)
+	}
+
+	return rom.ParseFormat(name)
+}
+
+// run executes the given program file and prints the final state of the computer
+func run(filename string, format rom.Format, cycles int, key uint16, ram ramValues, info *debugInfo) error {
+	words, err := rom.ReadFile(filename, format)
+	if err != nil {
+		return fmt.Errorf("can't read %s: %w", filename, err)
+	}
+
+	hack := computer.New()
+	if err := hack.LoadWords(words); err != nil {
+		return fmt.Errorf("can't load %s: %w", filename, err)
+	}
+
+	for address, value := range ram {
+		hack.RAM[address] = value
+	}
+	hack.SetKey(key)
+
+	executed, err := hack.Run(cycles)
+	if err != nil {
+		return err
+	}
+
+	printState(hack, executed, info)
+	return nil
+}
+
+// printState prints registers and the non-zero words of the RAM.
+// Addresses are annotated by the names and source lines from the debug info.
+func printState(hack *computer.Computer, executed int, info *debugInfo) {
+	status := "running"
+	if hack.Halted() {
+		status = "halted"
+	}
+
+	fmt.Printf("cycles: %d (%s)\n", executed, status)
+	fmt.Printf("A: %d\nD: %d\n", int16(hack.A), int16(hack.D))
+
+	fmt.Printf("PC: %d", hack.PC)
+	if labels := info.labels[hack.PC]; len(labels) > 0 {
+		fmt.Printf(" (%s)", strings.Join(labels, ", "))
+	}
+	if source, ok := info.source[hack.PC]; ok {
+		fmt.Printf(" %s", source)
+	}
+	fmt.Println()
+
+	fmt.Println("RAM:")
+	for address := 0; address < computer.RAMSize; address++ {
+		value := hack.RAM[address]
+		if value == 0 {
+			continue
+		}
+
+		fmt.Printf("  [%d] %d", address, int16(value))
+		if variables := info.variables[uint16(address)]; len(variables) > 0 {
+			fmt.Printf(" (%s)", strings.Join(variables, ", "))
+		}
+		fmt.Println()
+	}
+
+	screenWords := 0
+	for address := computer.Screen; address < computer.Screen+computer.ScreenSize; address++ {
+		if hack.RAM[address] != 0 {
+			screenWords++
+		}
+	}
+	fmt.Printf("SCREEN: %d non-zero words\n", screenWords)
+	fmt.Printf("KBD: %d\n", hack.RAM[computer.Keyboard])
+}
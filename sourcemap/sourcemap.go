@@ -0,0 +1,118 @@
+// Package sourcemap maps lines of the generated files and ROM addresses back to
+// the lines of their source files.
+package sourcemap
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Position is the line of the source file.
+type Position struct {
+	Filename string
+	Line     int
+}
+
+func (p Position) String() string { return fmt.Sprintf("%s:%d", p.Filename, p.Line) }
+
+// Entry maps the target, the line of the generated file or the ROM address, to the source position.
+type Entry struct {
+	Target int
+	Source Position
+}
+
+// Map contains the entries sorted by their targets. Targets without the entry,
+// e.g. the bootstrap code, have no source.
+type Map []Entry
+
+// Lookup returns the source position of the target.
+func (m Map) Lookup(target int) (Position, bool) {
+	i := sort.Search(len(m), func(i int) bool { return m[i].Target >= target })
+	if i == len(m) || m[i].Target != target {
+		return Position{}, false
+	}
+
+	return m[i].Source, true
+}
+
+// Compose follows the source positions through the maps of the generated files keyed
+// by their filenames, e.g. from the .asm lines to the .vm lines and further to the .jack lines.
+// Positions in files without the map are kept.
+func (m Map) Compose(maps map[string]Map) Map {
+	composed := make(Map, 0, len(m))
+
+	for _, entry := range m {
+		source, ok := entry.Source, true
+
+		// Every map is followed at most once, so the maps can't form a cycle
+		for followed := 0; followed < len(maps); followed++ {
+			next, found := maps[source.Filename]
+			if !found {
+				break
+			}
+
+			if source, ok = next.Lookup(source.Line); !ok {
+				break
+			}
+		}
+
+		if ok {
+			composed = append(composed, Entry{entry.Target, source})
+		}
+	}
+
+	return composed
+}
+
+// Write writes the map, one line per entry with tab separated target and file:line position.
+func (m Map) Write(w io.Writer) error {
+	var builder strings.Builder
+
+	for _, entry := range m {
+		fmt.Fprintf(&builder, "%d\t%s\n", entry.Target, entry.Source)
+	}
+
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
+
+// Read reads the map written by Write.
+func Read(input io.Reader) (Map, error) {
+	var m Map
+	scanner := bufio.NewScanner(input)
+
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.SplitN(scanner.Text(), "\t", 2)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected 2 tab separated fields", line)
+		}
+
+		target, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid target %q", line, fields[0])
+		}
+
+		i := strings.LastIndexByte(fields[1], ':')
+		if i == -1 {
+			return nil, fmt.Errorf("line %d: invalid position %q", line, fields[1])
+		}
+
+		source := Position{Filename: fields[1][:i]}
+		if source.Line, err = strconv.Atoi(fields[1][i+1:]); err != nil {
+			return nil, fmt.Errorf("line %d: invalid position %q", line, fields[1])
+		}
+
+		m = append(m, Entry{target, source})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(m, func(i, j int) bool { return m[i].Target < m[j].Target })
+	return m, nil
+}
@@ -0,0 +1,109 @@
+package sourcemap
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	m := Map{{2, Position{"Main.vm", 1}}, {5, Position{"Main.vm", 3}}}
+
+	if source, ok := m.Lookup(5); !ok || source != (Position{"Main.vm", 3}) {
+		t.Errorf("lookup of 5 = %v, %v, expected Main.vm:3", source, ok)
+	}
+
+	for _, target := range []int{0, 3, 6} {
+		if source, ok := m.Lookup(target); ok {
+			t.Errorf("lookup of %d = %v, expected no source", target, source)
+		}
+	}
+}
+
+func TestCompose(t *testing.T) {
+	rom := Map{
+		{0, Position{"Main.asm", 1}},
+		{1, Position{"Main.asm", 2}},
+		{2, Position{"Main.asm", 3}},
+		{3, Position{"Main.asm", 4}},
+		{4, Position{"Sys.asm", 7}},
+	}
+	maps := map[string]Map{
+		"Main.asm": {{2, Position{"Main.vm", 1}}, {3, Position{"Main.vm", 2}}, {4, Position{"Main.vm", 5}}},
+		"Main.vm":  {{1, Position{"Main.jack", 10}}, {2, Position{"Main.jack", 12}}},
+	}
+
+	// The bootstrap line 1 and the .vm line 5 have no source, Sys.asm has no map
+	expected := Map{
+		{1, Position{"Main.jack", 10}},
+		{2, Position{"Main.jack", 12}},
+		{4, Position{"Sys.asm", 7}},
+	}
+
+	if composed := rom.Compose(maps); !reflect.DeepEqual(composed, expected) {
+		t.Errorf("composed %v, expected %v", composed, expected)
+	}
+}
+
+func TestComposeCycle(t *testing.T) {
+	maps := map[string]Map{
+		"A.vm": {{1, Position{"B.vm", 1}}},
+		"B.vm": {{1, Position{"A.vm", 1}}},
+	}
+
+	composed := Map{{0, Position{"A.vm", 1}}}.Compose(maps)
+	if len(composed) != 1 || composed[0].Source.Line != 1 {
+		t.Errorf("composed %v, expected the position after following both maps", composed)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	m := Map{
+		{0, Position{"Main.jack", 3}},
+		{7, Position{"C:/games/Pong:v2.jack", 15}},
+		{12, Position{"dir with spaces/Sys.vm", 1}},
+	}
+
+	var output strings.Builder
+	if err := m.Write(&output); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := Read(strings.NewReader(output.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(read, m) {
+		t.Errorf("read %v, expected %v", read, m)
+	}
+}
+
+func TestReadSorts(t *testing.T) {
+	read, err := Read(strings.NewReader("5\tB.vm:2\n1\tA.vm:1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := (Map{{1, Position{"A.vm", 1}}, {5, Position{"B.vm", 2}}}); !reflect.DeepEqual(read, expected) {
+		t.Errorf("read %v, expected %v", read, expected)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"1 Main.vm:2\n", "line 1: expected 2 tab separated fields"},
+		{"0\tMain.vm:1\nx\tMain.vm:2\n", `line 2: invalid target "x"`},
+		{"1\tMain.vm\n", `line 1: invalid position "Main.vm"`},
+		{"1\tMain.vm:two\n", `line 1: invalid position "Main.vm:two"`},
+	}
+
+	for _, test := range tests {
+		if _, err := Read(strings.NewReader(test.input)); err == nil || err.Error() != test.err {
+			t.Errorf("read %q: got error %v, expected %s", test.input, err, test.err)
+		}
+	}
+}
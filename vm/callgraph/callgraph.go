@@ -0,0 +1,291 @@
+// Package callgraph builds the call graph of the VM program from its call commands.
+package callgraph
+
+import (
+	"strings"
+
+	"github.com/ProchazkaDavid/nand2tetris/vm/command"
+)
+
+// EntryFunction is the function called by the bootstrap code
+const EntryFunction = "Sys.init"
+
+// Graph is the call graph of the functions of the VM program, or of its classes, see Classes.
+type Graph struct {
+	// Nodes contains the defined functions in the order of the files followed by the called
+	// functions, which aren't defined, in the order of their first call
+	Nodes []*Node `json:"nodes"`
+	// Edges contains the calls in the order of their first call
+	Edges []*Edge `json:"edges"`
+	// calls maps the functions to the called functions, commands outside of functions are under the empty name
+	calls map[string][]string
+}
+
+// Node is the function, or the class, of the graph.
+type Node struct {
+	Name string `json:"name"`
+	// Entry is true for Sys.init, which is called by the bootstrap code
+	Entry bool `json:"entry,omitempty"`
+	// Uncalled is true for the defined functions, which aren't called by other functions
+	// or by the commands outside of functions
+	Uncalled bool `json:"uncalled,omitempty"`
+	// External is true for the functions of classes not present in the program, e.g. of the OS
+	External bool `json:"external,omitempty"`
+	// Undefined is true for the called functions of present classes, which aren't defined
+	Undefined bool `json:"undefined,omitempty"`
+	// Cycle numbers the recursive cycles from 1, the nodes of the same cycle call each other.
+	// The node isn't recursive if the Cycle is 0.
+	Cycle int `json:"cycle,omitempty"`
+}
+
+// Edge represents the calls of the function From to the function To.
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Calls is the number of the call commands
+	Calls int `json:"calls"`
+}
+
+// New builds the call graph of the functions from the call commands of the files.
+func New(files [][]command.Command) *Graph {
+	g := &Graph{calls: map[string][]string{}}
+	nodes := map[string]*Node{}
+	edges := map[[2]string]*Edge{}
+
+	for _, commands := range files {
+		function := ""
+		for _, cmd := range commands {
+			if cmd.Type == command.Function {
+				function = cmd.First
+				if _, ok := nodes[function]; !ok {
+					nodes[function] = &Node{Name: function}
+					g.Nodes = append(g.Nodes, nodes[function])
+				}
+			}
+		}
+	}
+
+	classes := map[string]bool{}
+	for _, node := range g.Nodes {
+		classes[className(node.Name)] = true
+	}
+
+	for _, commands := range files {
+		function := ""
+		for _, cmd := range commands {
+			switch cmd.Type {
+			case command.Function:
+				function = cmd.First
+			case command.Call:
+				g.calls[function] = append(g.calls[function], cmd.First)
+
+				if _, ok := nodes[cmd.First]; !ok {
+					external := !classes[className(cmd.First)]
+					nodes[cmd.First] = &Node{Name: cmd.First, External: external, Undefined: !external}
+					g.Nodes = append(g.Nodes, nodes[cmd.First])
+				}
+
+				if function != "" {
+					g.addEdge(edges, function, cmd.First, 1)
+				}
+			}
+		}
+	}
+
+	g.analyze(EntryFunction)
+	return g
+}
+
+// className returns the class of the function
+func className(function string) string {
+	if i := strings.IndexByte(function, '.'); i != -1 {
+		return function[:i]
+	}
+
+	return function
+}
+
+// addEdge adds the calls to the edge, the edge is created by the first call
+func (g *Graph) addEdge(edges map[[2]string]*Edge, from, to string, calls int) {
+	key := [2]string{from, to}
+	if edge, ok := edges[key]; ok {
+		edge.Calls += calls
+		return
+	}
+
+	edges[key] = &Edge{From: from, To: to, Calls: calls}
+	g.Edges = append(g.Edges, edges[key])
+}
+
+// Defined returns true if the function is defined in the program.
+func (g *Graph) Defined(function string) bool {
+	for _, node := range g.Nodes {
+		if node.Name == function {
+			return !node.External && !node.Undefined
+		}
+	}
+
+	return false
+}
+
+// Reachable returns the functions reachable by calls from the roots, the empty name
+// stands for the commands outside of functions.
+func (g *Graph) Reachable(roots ...string) map[string]bool {
+	visited := map[string]bool{}
+
+	stack := roots
+	for len(stack) > 0 {
+		function := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if visited[function] {
+			continue
+		}
+		visited[function] = true
+
+		stack = append(stack, g.calls[function]...)
+	}
+
+	return visited
+}
+
+// Classes returns the graph collapsed to the classes. Calls within the class are left out,
+// so the cycles are the mutually dependent classes.
+func (g *Graph) Classes() *Graph {
+	classes := &Graph{calls: map[string][]string{}}
+	nodes := map[string]*Node{}
+	edges := map[[2]string]*Edge{}
+
+	for _, node := range g.Nodes {
+		name := className(node.Name)
+		class, ok := nodes[name]
+		if !ok {
+			class = &Node{Name: name, External: node.External}
+			nodes[name] = class
+			classes.Nodes = append(classes.Nodes, class)
+		}
+	}
+
+	for from, called := range g.calls {
+		for _, to := range called {
+			if from != "" && className(from) == className(to) {
+				continue
+			}
+
+			if from == "" {
+				classes.calls[""] = append(classes.calls[""], className(to))
+			} else {
+				classes.calls[className(from)] = append(classes.calls[className(from)], className(to))
+			}
+		}
+	}
+
+	for _, edge := range g.Edges {
+		if from, to := className(edge.From), className(edge.To); from != to {
+			classes.addEdge(edges, from, to, edge.Calls)
+		}
+	}
+
+	classes.analyze(className(EntryFunction))
+	return classes
+}
+
+// analyze marks the entry point, the uncalled nodes and the recursive cycles
+func (g *Graph) analyze(entry string) {
+	called := map[string]bool{}
+	for _, edge := range g.Edges {
+		if edge.From != edge.To {
+			called[edge.To] = true
+		}
+	}
+
+	// Calls outside of functions have no edges, e.g. the calls of a single file without the bootstrap
+	for _, function := range g.calls[""] {
+		called[function] = true
+	}
+
+	for _, node := range g.Nodes {
+		node.Entry = node.Name == entry && !node.External && !node.Undefined
+		node.Uncalled = !node.Entry && !node.External && !node.Undefined && !called[node.Name]
+	}
+
+	g.markCycles()
+}
+
+// markCycles numbers the strongly connected components with more than one node,
+// or with the node calling itself, by Tarjan's algorithm
+func (g *Graph) markCycles() {
+	successors := map[string][]string{}
+	selfCalls := map[string]bool{}
+	for _, edge := range g.Edges {
+		successors[edge.From] = append(successors[edge.From], edge.To)
+		if edge.From == edge.To {
+			selfCalls[edge.From] = true
+		}
+	}
+
+	nodes := map[string]*Node{}
+	for _, node := range g.Nodes {
+		nodes[node.Name] = node
+	}
+
+	index := map[string]int{}
+	lowLink := map[string]int{}
+	onStack := map[string]bool{}
+	var stack []string
+	cycles := 0
+
+	var connect func(name string)
+	connect = func(name string) {
+		index[name] = len(index)
+		lowLink[name] = index[name]
+		stack = append(stack, name)
+		onStack[name] = true
+
+		for _, next := range successors[name] {
+			if _, visited := index[next]; !visited {
+				connect(next)
+				lowLink[name] = min(lowLink[name], lowLink[next])
+			} else if onStack[next] {
+				lowLink[name] = min(lowLink[name], index[next])
+			}
+		}
+
+		if lowLink[name] != index[name] {
+			return
+		}
+
+		var component []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+
+			if top == name {
+				break
+			}
+		}
+
+		if len(component) > 1 || selfCalls[name] {
+			cycles++
+			for _, member := range component {
+				nodes[member].Cycle = cycles
+			}
+		}
+	}
+
+	for _, node := range g.Nodes {
+		if _, visited := index[node.Name]; !visited {
+			connect(node.Name)
+		}
+	}
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,127 @@
+package callgraph
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ProchazkaDavid/nand2tetris/vm/command"
+)
+
+// parse parses the VM code of the test file
+func parse(t *testing.T, code string) []command.Command {
+	t.Helper()
+
+	commands, err := command.Parse(strings.NewReader(code), "Test.vm")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return commands
+}
+
+// nodes returns the nodes of the graph by their names
+func nodes(g *Graph) map[string]Node {
+	result := map[string]Node{}
+	for _, node := range g.Nodes {
+		result[node.Name] = *node
+	}
+
+	return result
+}
+
+func TestNew(t *testing.T) {
+	g := New([][]command.Command{parse(t, `
+call Main.main 0
+function Main.main 0
+call Main.even 1
+call Math.multiply 2
+call Main.missing 0
+return
+function Main.even 0
+call Main.odd 1
+return
+function Main.odd 0
+call Main.even 1
+return
+function Main.unused 0
+call Main.unused 0
+return
+`)})
+
+	expected := map[string]Node{
+		"Main.main":     {Name: "Main.main"},
+		"Main.even":     {Name: "Main.even", Cycle: 1},
+		"Main.odd":      {Name: "Main.odd", Cycle: 1},
+		"Main.unused":   {Name: "Main.unused", Uncalled: true, Cycle: 2},
+		"Math.multiply": {Name: "Math.multiply", External: true},
+		"Main.missing":  {Name: "Main.missing", Undefined: true},
+	}
+
+	actual := nodes(g)
+	if len(actual) != len(expected) {
+		t.Errorf("got %d nodes, expected %d", len(actual), len(expected))
+	}
+
+	for name, node := range expected {
+		if actual[name] != node {
+			t.Errorf("got %+v, expected %+v", actual[name], node)
+		}
+	}
+
+	if !g.Defined("Main.odd") || g.Defined("Math.multiply") || g.Defined("Main.missing") {
+		t.Error("expected only Main.odd to be defined")
+	}
+
+	reachable := g.Reachable("")
+	for _, function := range []string{"Main.main", "Main.even", "Main.odd", "Math.multiply"} {
+		if !reachable[function] {
+			t.Errorf("%s isn't reachable", function)
+		}
+	}
+	if reachable["Main.unused"] {
+		t.Error("Main.unused is reachable")
+	}
+}
+
+func TestEntry(t *testing.T) {
+	g := New([][]command.Command{parse(t, `
+function Sys.init 0
+call Main.main 0
+return
+function Main.main 0
+return
+`)})
+
+	actual := nodes(g)
+	if !actual["Sys.init"].Entry || actual["Sys.init"].Uncalled || actual["Main.main"].Uncalled {
+		t.Errorf("unexpected nodes %+v", actual)
+	}
+}
+
+func TestClasses(t *testing.T) {
+	g := New([][]command.Command{parse(t, `
+function Sys.init 0
+call Main.main 0
+return
+function Main.main 0
+call Main.helper 0
+call Game.run 0
+return
+function Main.helper 0
+return
+function Game.run 0
+call Main.helper 0
+return
+`)}).Classes()
+
+	actual := nodes(g)
+	if !actual["Sys"].Entry || actual["Main"].Cycle == 0 || actual["Main"].Cycle != actual["Game"].Cycle {
+		t.Errorf("expected the cycle of Main and Game, got %+v", actual)
+	}
+
+	for _, edge := range g.Edges {
+		if edge.From == edge.To {
+			t.Errorf("unexpected call within the class %s", edge.From)
+		}
+	}
+}
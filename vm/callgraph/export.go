@@ -0,0 +1,57 @@
+package callgraph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteJSON writes the graph as the JSON object with the nodes and the edges.
+func (g *Graph) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(g)
+}
+
+// WriteDOT writes the graph in the Graphviz DOT language. The entry point is bold,
+// external and undefined functions are dashed, uncalled functions are gray
+// and the recursive cycles are red. Edges are labeled by the number of calls.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var builder strings.Builder
+
+	builder.WriteString("digraph calls {\n")
+	builder.WriteString("\tnode [shape=box];\n")
+
+	for _, node := range g.Nodes {
+		var attributes []string
+		switch {
+		case node.Entry:
+			attributes = append(attributes, "style=bold")
+		case node.External || node.Undefined:
+			attributes = append(attributes, "style=dashed")
+		case node.Uncalled:
+			attributes = append(attributes, "color=gray", "fontcolor=gray")
+		}
+
+		if node.Cycle > 0 {
+			attributes = append(attributes, "color=red", fmt.Sprintf("xlabel=\"cycle %d\"", node.Cycle))
+		}
+
+		fmt.Fprintf(&builder, "\t%q", node.Name)
+		if len(attributes) > 0 {
+			fmt.Fprintf(&builder, " [%s]", strings.Join(attributes, ", "))
+		}
+		builder.WriteString(";\n")
+	}
+
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&builder, "\t%q -> %q [label=%d];\n", edge.From, edge.To, edge.Calls)
+	}
+
+	builder.WriteString("}\n")
+
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
@@ -2,10 +2,6 @@ package code
 
 import "fmt"
 
-// counters for keeping the eq and compare operations unique across the .asm file
-var eqCounter = 0
-var compareCounter = 0
-
 // instruction maps .vm arithmetic operation to the corresponding .asm operation
 var instruction = map[string]string{
 	"add": "+",
@@ -24,11 +20,21 @@ func (cw *Writer) WriteArithmetic(operation string) error {
 	case "add", "sub", "and", "or":
 		return cw.write(binaryOperation(operation))
 	case "lt", "gt":
-		return cw.write(compare(operation))
+		cw.counters.Compare++
+		if cw.compact {
+			return cw.write(cw.compareSite(operation, fmt.Sprintf("COMP_%d", cw.counters.Compare)))
+		}
+		return cw.write(compare(operation, cw.counters.Compare))
 	case "neg", "not":
 		return cw.write(unaryOperation(operation))
+	case "eq":
+		cw.counters.Eq++
+		if cw.compact {
+			return cw.write(cw.compareSite(operation, fmt.Sprintf("EQ_%d", cw.counters.Eq)))
+		}
+		return cw.write(eqInstructions(cw.counters.Eq))
 	default:
-		return cw.write(eqInstructions())
+		return fmt.Errorf("unknown arithmetic command %q", operation)
 	}
 }
 
@@ -55,9 +61,8 @@ func binaryOperation(operation string) []string {
 	}
 }
 
-// Generates instructions for eq
-func eqInstructions() []string {
-	eqCounter++
+// Generates instructions for eq, the label is numbered by the counter
+func eqInstructions(counter int) []string {
 	return []string{
 		"// eq",
 		"@SP",
@@ -67,18 +72,17 @@ func eqInstructions() []string {
 		"A=A-1",
 		"D=M-D",
 		"M=-1",
-		fmt.Sprintf("@EQ_%d", eqCounter),
+		fmt.Sprintf("@EQ_%d", counter),
 		"D;JEQ",
 		"@SP",
 		"A=M-1",
 		"M=0",
-		fmt.Sprintf("(EQ_%d)", eqCounter),
+		fmt.Sprintf("(EQ_%d)", counter),
 	}
 }
 
-// Generates instructions for gt, lt
-func compare(operation string) []string {
-	compareCounter++
+// Generates instructions for gt, lt, the label is numbered by the counter
+func compare(operation string, counter int) []string {
 	return []string{
 		fmt.Sprintf("// %s", operation),
 		"@SP",
@@ -88,11 +92,11 @@ func compare(operation string) []string {
 		"A=A-1",
 		"D=M-D",
 		"M=-1",
-		fmt.Sprintf("@COMP_%d", compareCounter),
+		fmt.Sprintf("@COMP_%d", counter),
 		fmt.Sprintf("D;J%s", instruction[operation]),
 		"@SP",
 		"A=M-1",
 		"M=0",
-		fmt.Sprintf("(COMP_%d)", compareCounter),
+		fmt.Sprintf("(COMP_%d)", counter),
 	}
 }
@@ -0,0 +1,162 @@
+package code
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Labels of the shared routines of the compact code. Labels of the VM code can't contain "$$".
+const (
+	callRoutine   = "$$CALL"
+	returnRoutine = "$$RETURN"
+	// haltLabel stops the program before the shared routines at its end
+	haltLabel = "$$HALT"
+)
+
+// compareRoutines maps the comparison to the label of its shared routine
+var compareRoutines = map[string]string{
+	"eq": "$$EQ",
+	"gt": "$$GT",
+	"lt": "$$LT",
+}
+
+// SetCompact enables the size-optimized code. Calls, returns and comparisons jump into
+// shared routines with parameters in R13-R15 instead of inlining the whole code,
+// the routines are written by WriteRoutines.
+func (cw *Writer) SetCompact(compact bool) { cw.compact = compact }
+
+// callSite generates the call of the shared call routine with the number of arguments in R13,
+// the address of the function in R14 and the return address in D
+func (cw *Writer) callSite(function string, arguments int, returnLabel string) []string {
+	cw.routines[callRoutine] = true
+
+	return []string{
+		fmt.Sprintf("// call %s %d", function, arguments),
+		fmt.Sprintf("@%d", arguments),
+		"D=A",
+		"@R13",
+		"M=D",
+		fmt.Sprintf("@%s", function),
+		"D=A",
+		"@R14",
+		"M=D",
+		fmt.Sprintf("@%s", returnLabel),
+		"D=A",
+		fmt.Sprintf("@%s", callRoutine),
+		"0;JMP",
+		fmt.Sprintf("(%s)", returnLabel),
+	}
+}
+
+// compareSite generates the call of the shared comparison routine with the return address in R15
+func (cw *Writer) compareSite(operation, returnLabel string) []string {
+	cw.routines[compareRoutines[operation]] = true
+
+	return []string{
+		fmt.Sprintf("// %s", operation),
+		fmt.Sprintf("@%s", returnLabel),
+		"D=A",
+		"@R15",
+		"M=D",
+		fmt.Sprintf("@%s", compareRoutines[operation]),
+		"0;JMP",
+		fmt.Sprintf("(%s)", returnLabel),
+	}
+}
+
+// callRoutineInstructions generates the shared call routine, which saves the frame
+// of the caller like the inlined call
+func callRoutineInstructions() []string {
+	instructions := []string{
+		fmt.Sprintf("(%s)", callRoutine),
+		"@SP",
+		"AM=M+1",
+		"A=A-1",
+		"M=D",
+	}
+
+	for _, segment := range [...]string{"LCL", "ARG", "THIS", "THAT"} {
+		instructions = append(instructions, []string{
+			fmt.Sprintf("@%s", segment),
+			"D=M",
+			"@SP",
+			"AM=M+1",
+			"A=A-1",
+			"M=D",
+		}...)
+	}
+
+	return append(instructions, []string{
+		"@SP",
+		"D=M",
+		"@5",
+		"D=D-A",
+		"@R13",
+		"D=D-M",
+		"@ARG",
+		"M=D",
+		"@SP",
+		"D=M",
+		"@LCL",
+		"M=D",
+		"@R14",
+		"A=M",
+		"0;JMP",
+	}...)
+}
+
+// compareRoutineInstructions generates the shared routine of the eq, gt or lt comparison
+func compareRoutineInstructions(operation string) []string {
+	label := compareRoutines[operation]
+
+	return []string{
+		fmt.Sprintf("(%s)", label),
+		"@SP",
+		"AM=M-1",
+		"D=M",
+		"A=A-1",
+		"D=M-D",
+		"M=-1",
+		fmt.Sprintf("@%s$TRUE", label),
+		fmt.Sprintf("D;J%s", strings.ToUpper(operation)),
+		"@SP",
+		"A=M-1",
+		"M=0",
+		fmt.Sprintf("(%s$TRUE)", label),
+		"@R15",
+		"A=M",
+		"0;JMP",
+	}
+}
+
+// WriteRoutines writes the shared routines used by the compact code, preceded by the halt loop,
+// so the program doesn't run into them. Should be called after all VM commands are written.
+func (cw *Writer) WriteRoutines() error {
+	if len(cw.routines) == 0 {
+		return nil
+	}
+
+	instructions := []string{
+		"// Shared routines",
+		fmt.Sprintf("(%s)", haltLabel),
+		fmt.Sprintf("@%s", haltLabel),
+		"0;JMP",
+	}
+
+	if cw.routines[callRoutine] {
+		instructions = append(instructions, callRoutineInstructions()...)
+	}
+
+	if cw.routines[returnRoutine] {
+		instructions = append(instructions, fmt.Sprintf("(%s)", returnRoutine))
+		instructions = append(instructions, returnInstructions()...)
+	}
+
+	for _, operation := range [...]string{"eq", "gt", "lt"} {
+		if cw.routines[compareRoutines[operation]] {
+			instructions = append(instructions, compareRoutineInstructions(operation)...)
+		}
+	}
+
+	return cw.write(instructions)
+}
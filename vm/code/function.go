@@ -2,9 +2,6 @@ package code
 
 import "fmt"
 
-// counter for keeping the call operations unique across the .asm file
-var callCounter = 0
-
 // WriteFunction writes function command to the the assembly file.
 func (cw *Writer) WriteFunction(name string, variables int) error {
 	instructions := []string{
@@ -34,7 +31,11 @@ func (cw *Writer) WriteFunction(name string, variables int) error {
 
 // WriteCall writes call command to the the assembly file.
 func (cw *Writer) WriteCall(function string, arguments int) error {
-	callCounter++
+	cw.counters.Call++
+
+	if cw.compact {
+		return cw.write(cw.callSite(function, arguments, fmt.Sprintf("%s$ret.%d", function, cw.counters.Call)))
+	}
 
 	instructions := []string{
 		fmt.Sprintf("// call %s %d", function, arguments),
@@ -42,7 +43,7 @@ func (cw *Writer) WriteCall(function string, arguments int) error {
 		"D=M",
 		"@R13",
 		"M=D",
-		fmt.Sprintf("@%s$ret.%d", function, callCounter),
+		fmt.Sprintf("@%s$ret.%d", function, cw.counters.Call),
 		"D=A",
 		"@SP",
 		"M=M+1",
@@ -76,14 +77,23 @@ func (cw *Writer) WriteCall(function string, arguments int) error {
 		"M=D",
 		fmt.Sprintf("@%s", function),
 		"0;JMP",
-		fmt.Sprintf("(%s$ret.%d)", function, callCounter),
+		fmt.Sprintf("(%s$ret.%d)", function, cw.counters.Call),
 	}...))
 }
 
 // WriteReturn writes return command to the the assembly file.
 func (cw *Writer) WriteReturn() error {
+	if cw.compact {
+		cw.routines[returnRoutine] = true
+		return cw.write([]string{"// return", "@" + returnRoutine, "0;JMP"})
+	}
+
+	return cw.write(append([]string{"// return"}, returnInstructions()...))
+}
+
+// returnInstructions generates instructions of the return
+func returnInstructions() []string {
 	instructions := []string{
-		"// return",
 		"@LCL",
 		"D=M",
 		"@R13",
@@ -117,9 +127,9 @@ func (cw *Writer) WriteReturn() error {
 		}...)
 	}
 
-	return cw.write(append(instructions, []string{
+	return append(instructions, []string{
 		"@R14",
 		"A=M",
 		"0;JMP",
-	}...))
+	}...)
 }
@@ -16,33 +16,60 @@ var pointers = map[int]string{
 	1: "THAT",
 }
 
+// checkIndex checks the index of the fixed size pointer and temp segments
+func checkIndex(segment string, index int) error {
+	if _, ok := pointers[index]; segment == "pointer" && !ok {
+		return fmt.Errorf("pointer index %d is outside of the range 0-1", index)
+	}
+
+	if segment == "temp" && (index < 0 || index > 7) {
+		return fmt.Errorf("temp index %d is outside of the range 0-7", index)
+	}
+
+	return nil
+}
+
 // WritePush writes to the output file the assembly code that implements Push/Pop command.
 func (cw *Writer) WritePush(segment string, index int) error {
+	if err := checkIndex(segment, index); err != nil {
+		return err
+	}
+
 	switch segment {
 	case "constant":
 		return cw.write(constant(index))
 	case "static":
+		cw.statics[fmt.Sprintf("%s.%d", cw.filename, index)] = true
 		return cw.write(pushStatic(index, cw.filename))
 	case "temp":
 		return cw.write(pushTemp(index))
 	case "pointer":
 		return cw.write(pushPointer(index))
-	default:
+	case "local", "argument", "this", "that":
 		return cw.write(push(segment, index))
+	default:
+		return fmt.Errorf("unknown segment %q", segment)
 	}
 }
 
 // WritePop writes to the output file the assembly code that implements Push/Pop command.
 func (cw *Writer) WritePop(segment string, index int) error {
+	if err := checkIndex(segment, index); err != nil {
+		return err
+	}
+
 	switch segment {
 	case "static":
+		cw.statics[fmt.Sprintf("%s.%d", cw.filename, index)] = true
 		return cw.write(popStatic(index, cw.filename))
 	case "temp":
 		return cw.write(popTemp(index))
 	case "pointer":
 		return cw.write(popPointer(index))
-	default:
+	case "local", "argument", "this", "that":
 		return cw.write(pop(segment, index))
+	default:
+		return fmt.Errorf("can't pop to the %s segment", segment)
 	}
 }
 
@@ -5,12 +5,42 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+
+	"github.com/ProchazkaDavid/nand2tetris/vm/command"
 )
 
 // Writer writes corresponding .asm instructions for VM commands
 type Writer struct {
 	output   io.StringWriter
 	filename string
+	// statics contains the symbols of the static variables of all files
+	statics map[string]bool
+	// compact enables the shared call, return and comparison routines, see SetCompact
+	compact bool
+	// routines contains the shared routines used by the compact code
+	routines map[string]bool
+	counters Counters
+	// lines is the number of lines written so far
+	lines int
+}
+
+// Counters number the labels of the comparisons and calls, which must be unique across the .asm file.
+type Counters struct {
+	Eq      int
+	Compare int
+	Call    int
+}
+
+// Count advances the counters by the labels generated for the command.
+func (c *Counters) Count(cmd command.Command) {
+	switch {
+	case cmd.Type == command.Call:
+		c.Call++
+	case cmd.Type == command.Arithmetic && cmd.First == "eq":
+		c.Eq++
+	case cmd.Type == command.Arithmetic && (cmd.First == "gt" || cmd.First == "lt"):
+		c.Compare++
+	}
 }
 
 // NewWriter opens the output file and gets ready to write into it.
@@ -18,9 +48,49 @@ func NewWriter(output io.StringWriter, filename string) *Writer {
 	return &Writer{
 		output:   output,
 		filename: strings.TrimSuffix(path.Base(filename), filepath.Ext(filename)),
+		statics:  map[string]bool{},
+		routines: map[string]bool{},
 	}
 }
 
+// Statics returns the number of distinct static variables written so far
+func (cw *Writer) Statics() int { return len(cw.statics) }
+
+// Lines returns the number of lines written so far
+func (cw *Writer) Lines() int { return cw.lines }
+
+// Counters returns the counters of the labels written so far
+func (cw *Writer) Counters() Counters { return cw.counters }
+
+// Fork creates the writer of the .vm file into its own output, which numbers its labels
+// from the counters and shares the options of the cw. The fork can be written concurrently
+// with other forks and is merged back by Join.
+func (cw *Writer) Fork(output io.StringWriter, filename string, counters Counters) *Writer {
+	fork := NewWriter(output, filename)
+	fork.compact = cw.compact
+	fork.counters = counters
+
+	return fork
+}
+
+// Join writes the code of the fork into the output and takes over its static variables,
+// shared routines, counters and lines. Forks have to be joined in the order of their counters.
+func (cw *Writer) Join(fork *Writer, code string) error {
+	for symbol := range fork.statics {
+		cw.statics[symbol] = true
+	}
+
+	for routine := range fork.routines {
+		cw.routines[routine] = true
+	}
+
+	cw.counters = fork.counters
+	cw.lines += fork.lines
+
+	_, err := cw.output.WriteString(code)
+	return err
+}
+
 // SetFilename sets a new filename
 func (cw *Writer) SetFilename(filename string) {
 	cw.filename = strings.TrimSuffix(path.Base(filename), filepath.Ext(filename))
@@ -50,6 +120,8 @@ func (cw *Writer) write(instructions []string) error {
 		builder.WriteRune('\n')
 	}
 
+	cw.lines += len(instructions)
+
 	_, err := cw.output.WriteString(builder.String())
 	return err
 }
@@ -0,0 +1,36 @@
+// Package command parses the commands of the VM code.
+package command
+
+// Type represents command type
+type Type int
+
+const (
+	// Arithmetic command
+	Arithmetic Type = iota
+	// Push command
+	Push
+	// Pop command
+	Pop
+	// Label command
+	Label
+	// Goto command
+	Goto
+	// If command
+	If
+	// Function command
+	Function
+	// Return command
+	Return
+	// Call command
+	Call
+)
+
+// Command is the parsed command at the line of the .vm file. First is the first argument,
+// or the command itself in the case of Arithmetic, Second is the second argument of the
+// Push, Pop, Function and Call.
+type Command struct {
+	Type   Type
+	First  string
+	Second int
+	Line   int
+}
@@ -0,0 +1,37 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Error represents an error at the line of the .vm file.
+type Error struct {
+	Filename string
+	Line     int
+	Message  string
+}
+
+// Error returns the error prefixed by the File.vm:LINE position.
+func (e *Error) Error() string { return fmt.Sprintf("%s:%d: %s", e.Filename, e.Line, e.Message) }
+
+// ErrorList collects all errors found in the .vm file.
+type ErrorList []*Error
+
+func (l ErrorList) Error() string {
+	messages := make([]string, 0, len(l))
+	for _, err := range l {
+		messages = append(messages, err.Error())
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+// Err returns the list as an error, or nil if the list is empty.
+func (l ErrorList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+
+	return l
+}
@@ -0,0 +1,215 @@
+package command
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Parser parses an .vm file and provides convenient access to the command's arguments.
+// In addition, removes all white space and comments.
+type Parser struct {
+	scanner *bufio.Scanner
+	command []string
+	// filename is used in positions of errors
+	filename string
+	// line is the number of the current line of the input
+	line int
+}
+
+// NewParser creates new parser of the file, the filename is used in positions of errors.
+func NewParser(file io.Reader, filename string) (*Parser, error) {
+	return &Parser{scanner: bufio.NewScanner(file), command: []string{}, filename: filename}, nil
+}
+
+// HasMoreCommands returns true if there are more commands in the input.
+// Skips empty lines and comments.
+func (p *Parser) HasMoreCommands() bool {
+	for p.scanner.Scan() {
+		p.line++
+		if !ignoreCommand(p.scanner.Text()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Advance reads the next command from the input and makes it the current command.
+// Should be called only if HasMoreCommands() is true.
+// Initially there is no current command.
+func (p *Parser) Advance() {
+	p.command = strings.Fields(stripComment(p.scanner.Text()))
+}
+
+// Line returns the line number of the current command.
+func (p *Parser) Line() int { return p.line }
+
+// ignoreCommand defines which types of commands to ignore
+func ignoreCommand(command string) bool { return strings.TrimSpace(stripComment(command)) == "" }
+
+// stripComment removes the comment after the command
+func stripComment(command string) string {
+	if i := strings.Index(command, "//"); i != -1 {
+		return command[:i]
+	}
+
+	return command
+}
+
+// CommandType return one of defined command type constants
+func (p *Parser) CommandType() Type {
+	switch p.command[0] {
+	case "push":
+		return Push
+	case "pop":
+		return Pop
+	case "label":
+		return Label
+	case "goto":
+		return Goto
+	case "if-goto":
+		return If
+	case "function":
+		return Function
+	case "call":
+		return Call
+	case "return":
+		return Return
+	default:
+		return Arithmetic
+	}
+}
+
+// FirstArgument returns the first argument of the current command.
+// In the case of Arithmetic the command itself (add, sub, etc.) is returned.
+// Should not be called if the current command is Return.
+func (p *Parser) FirstArgument() string {
+	if len(p.command) == 1 {
+		return p.command[0]
+	}
+
+	return p.command[1]
+}
+
+// SecondArgument returns the second argument of the command.
+// Should be called only if the current command is Push, Pop, Function, or Call.
+func (p *Parser) SecondArgument() int {
+	i, err := strconv.ParseUint(p.command[2], 10, 15)
+	if err != nil {
+		return 0
+	}
+
+	return int(i)
+}
+
+// arguments contains the number of arguments of every command
+var arguments = map[string]int{
+	"add": 0, "sub": 0, "neg": 0, "eq": 0, "gt": 0, "lt": 0, "and": 0, "or": 0, "not": 0,
+	"push": 2, "pop": 2,
+	"label": 1, "goto": 1, "if-goto": 1,
+	"function": 2, "call": 2, "return": 0,
+}
+
+// segmentSizes contains the sizes of the segments, zero for unlimited segments
+var segmentSizes = map[string]int{
+	"argument": 0, "local": 0, "static": 0, "constant": 0,
+	"this": 0, "that": 0, "pointer": 2, "temp": 8,
+}
+
+// symbolPattern matches names of labels and functions - letters, digits, '_', '.' and ':'
+// not starting with a digit
+var symbolPattern = regexp.MustCompile(`^[A-Za-z_.:][A-Za-z0-9_.:]*$`)
+
+// Validate checks the current command, its number of arguments, the segment, the index
+// and names of labels and functions. Returns the error at the File.vm:LINE position,
+// or nil if the command is valid.
+func (p *Parser) Validate() *Error {
+	errorf := func(format string, args ...interface{}) *Error {
+		return &Error{p.filename, p.line, fmt.Sprintf(format, args...)}
+	}
+
+	name := p.command[0]
+	count, ok := arguments[name]
+	switch {
+	case !ok:
+		return errorf("unknown command %q", name)
+	case len(p.command)-1 != count:
+		return errorf("%s expects %d arguments, got %d", name, count, len(p.command)-1)
+	}
+
+	if count == 2 {
+		if _, err := strconv.ParseUint(p.command[2], 10, 15); err != nil {
+			return errorf("invalid %s argument %q, expected number 0-32767", name, p.command[2])
+		}
+	}
+
+	switch p.CommandType() {
+	case Push, Pop:
+		if err := CheckSegment(p.FirstArgument(), p.SecondArgument(), p.CommandType() == Pop); err != nil {
+			return errorf("%v", err)
+		}
+
+	case Label, Goto, If, Function, Call:
+		if !symbolPattern.MatchString(p.FirstArgument()) {
+			return errorf("illegal %s name %q", name, p.FirstArgument())
+		}
+	}
+
+	return nil
+}
+
+// CheckSegment checks the segment and the index of the push command, or of the pop command if pop is true.
+func CheckSegment(segment string, index int, pop bool) error {
+	size, ok := segmentSizes[segment]
+
+	switch {
+	case !ok:
+		return fmt.Errorf("unknown segment %q", segment)
+	case pop && segment == "constant":
+		return fmt.Errorf("can't pop to the constant segment")
+	case size > 0 && index >= size:
+		return fmt.Errorf("%s index %d is outside of the range 0-%d", segment, index, size-1)
+	}
+
+	return nil
+}
+
+// Parse reads and validates all commands of the input, the filename is used in positions of errors.
+// Invalid commands are returned at once as the ErrorList.
+func Parse(input io.Reader, filename string) ([]Command, error) {
+	parser, err := NewParser(input, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var commands []Command
+	var errors ErrorList
+	for parser.HasMoreCommands() {
+		parser.Advance()
+
+		if err := parser.Validate(); err != nil {
+			errors = append(errors, err)
+			continue
+		}
+
+		cmd := Command{Type: parser.CommandType(), Line: parser.Line()}
+		if cmd.Type != Return {
+			cmd.First = parser.FirstArgument()
+		}
+		if len(parser.command) > 2 {
+			cmd.Second = parser.SecondArgument()
+		}
+
+		commands = append(commands, cmd)
+	}
+
+	if err := parser.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("can't read %s: %w", filename, err)
+	}
+
+	return commands, errors.Err()
+}
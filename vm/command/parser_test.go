@@ -0,0 +1,116 @@
+package command
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		code string
+		err  string
+	}{
+		{"push constant 7", ""},
+		{"pop pointer 1", ""},
+		{"push temp 7", ""},
+		{"label IF_TRUE.1:end", ""},
+		{"call Main.main 0", ""},
+		{"function _helper 2 // comment", ""},
+		{"foo", `unknown command "foo"`},
+		{"add 1", "add expects 0 arguments, got 1"},
+		{"push constant", "push expects 2 arguments, got 1"},
+		{"return 0", "return expects 0 arguments, got 1"},
+		{"push local -1", `invalid push argument "-1", expected number 0-32767`},
+		{"call Main.f x", `invalid call argument "x", expected number 0-32767`},
+		{"push constant 32768", `invalid push argument "32768", expected number 0-32767`},
+		{"push heap 0", `unknown segment "heap"`},
+		{"pop constant 1", "can't pop to the constant segment"},
+		{"push pointer 2", "pointer index 2 is outside of the range 0-1"},
+		{"pop temp 8", "temp index 8 is outside of the range 0-7"},
+		{"label 1LOOP", `illegal label name "1LOOP"`},
+		{"goto END-1", `illegal goto name "END-1"`},
+		{"function Main.f-g 0", `illegal function name "Main.f-g"`},
+	}
+
+	for _, test := range tests {
+		parser, _ := NewParser(strings.NewReader(test.code), "Test.vm")
+		parser.HasMoreCommands()
+		parser.Advance()
+
+		err := parser.Validate()
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%q: unexpected error %v", test.code, err)
+		case test.err != "" && (err == nil || err.Message != test.err):
+			t.Errorf("%q: got error %v, expected %q", test.code, err, test.err)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	code := `// comment
+function Main.main 1
+	push constant 7 // seven
+	pop local 0
+label LOOP
+	add
+	return
+`
+
+	commands, err := Parse(strings.NewReader(code), "Main.vm")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Command{
+		{Function, "Main.main", 1, 2},
+		{Push, "constant", 7, 3},
+		{Pop, "local", 0, 4},
+		{Label, "LOOP", 0, 5},
+		{Arithmetic, "add", 0, 6},
+		{Return, "", 0, 7},
+	}
+	if !reflect.DeepEqual(commands, expected) {
+		t.Errorf("parsed %v, expected %v", commands, expected)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	code := "push constant 1\npop constant 0\n\npush temp 9\nmul\ngoto\n"
+
+	_, err := Parse(strings.NewReader(code), "Main.vm")
+	expected := ErrorList{
+		{"Main.vm", 2, "can't pop to the constant segment"},
+		{"Main.vm", 4, "temp index 9 is outside of the range 0-7"},
+		{"Main.vm", 5, `unknown command "mul"`},
+		{"Main.vm", 6, "goto expects 1 arguments, got 0"},
+	}
+
+	if !reflect.DeepEqual(err, expected) {
+		t.Errorf("got errors\n%v\nexpected\n%v", err, expected)
+	}
+}
+
+func TestCheckSegment(t *testing.T) {
+	tests := []struct {
+		segment string
+		index   int
+		pop     bool
+		err     string
+	}{
+		{"constant", 32767, false, ""},
+		{"constant", 0, true, "can't pop to the constant segment"},
+		{"pointer", 1, true, ""},
+		{"pointer", 2, false, "pointer index 2 is outside of the range 0-1"},
+		{"static", 300, true, ""},
+		{"stack", 0, false, `unknown segment "stack"`},
+	}
+
+	for _, test := range tests {
+		err := CheckSegment(test.segment, test.index, test.pop)
+		if (err == nil) != (test.err == "") || err != nil && err.Error() != test.err {
+			t.Errorf("CheckSegment(%s, %d, %v) = %v, expected %q", test.segment, test.index, test.pop, err, test.err)
+		}
+	}
+}
@@ -1,34 +1,82 @@
 package main
 
 import (
+	"bytes"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
 
-	"github.com/ProchazkaDavid/nand2tetris/vm/code"
+	"github.com/ProchazkaDavid/nand2tetris/assembler/asm"
+	"github.com/ProchazkaDavid/nand2tetris/sourcemap"
+	"github.com/ProchazkaDavid/nand2tetris/vm/translator"
+	"github.com/ProchazkaDavid/nand2tetris/vm/wasm"
 )
 
+// options configures the translation
+type options struct {
+	optimize  bool
+	compact   bool
+	eliminate bool
+	sourceMap bool
+	verify    bool
+	ram       asm.RAMCheck
+	// wasm selects the WebAssembly text format instead of the assembly
+	wasm bool
+}
+
 func main() {
-	if len(os.Args) != 2 {
+	optimize := flag.Bool("O", false, "run the peephole optimizer on the generated assembly")
+	compact := flag.Bool("compact", false, "share the call, return and comparison routines and report the saved instructions")
+	eliminate := flag.Bool("eliminate", false, "translate only functions reachable from Sys.init and report the removed functions")
+	sourceMap := flag.Bool("map", false, "write the map of the .asm lines to the .vm lines into the .asm.map file")
+	verify := flag.Bool("verify", false, "verify the stack depth, labels and calls of the whole program before the translation")
+	ramCheck := flag.String("ram", "warn", "report static variables outside of the static region 16-255: warn, error or ignore")
+	target := flag.String("target", "asm", "output format: asm (Hack assembly) or wasm (WebAssembly text format)")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
 		log.Fatalln("expected one argument - file or folder")
 	}
 
-	if err := run(os.Args[1]); err != nil {
+	check, err := asm.ParseRAMCheck(*ramCheck)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if *target != "asm" && *target != "wasm" {
+		log.Fatalf("unknown target %q, expected asm or wasm\n", *target)
+	}
+
+	if err := run(flag.Arg(0), options{*optimize, *compact, *eliminate, *sourceMap, *verify, check, *target == "wasm"}); err != nil {
 		log.Fatalln(err)
 	}
 }
 
 // run translates given file or folder
-func run(path string) error {
+func run(path string, opts options) error {
+	if opts.optimize && opts.sourceMap {
+		return fmt.Errorf("the optimized assembly has no source map, -O can't be combined with -map")
+	}
+
+	if opts.wasm && (opts.optimize || opts.compact || opts.sourceMap) {
+		return fmt.Errorf("-O, -compact and -map are options of the assembly, can't be combined with -target wasm")
+	}
+
 	inputFileInfo, err := os.Stat(path)
 	if err != nil {
 		return fmt.Errorf("can't get info about the input: %w", err)
 	}
 
+	extension := ".asm"
+	if opts.wasm {
+		extension = ".wat"
+	}
+
 	// Setup output file and files for parser
-	ouputFilename := strings.TrimSuffix(path, filepath.Ext(path)) + ".asm"
+	ouputFilename := strings.TrimSuffix(path, filepath.Ext(path)) + extension
 	files := []string{path}
 
 	inputIsDirectory := inputFileInfo.IsDir()
@@ -41,76 +89,146 @@ func run(path string) error {
 			return fmt.Errorf("can't get input files: %w", err)
 		}
 
-		ouputFilename = filepath.Join(path, filepath.Base(path)+".asm")
+		ouputFilename = filepath.Join(path, filepath.Base(path)+extension)
+	} else if opts.eliminate {
+		return fmt.Errorf("dead-function elimination needs the whole program, expected folder")
 	}
 
-	outputFile, err := os.Create(ouputFilename)
+	vmFiles, err := readFiles(files)
 	if err != nil {
-		return fmt.Errorf("can't open the output file: %w", err)
+		return err
 	}
-	defer outputFile.Close()
 
-	writer := code.NewWriter(outputFile, ouputFilename)
+	// The optimizer needs the whole program, so the code is buffered
+	translated, vmTranslator, err := translate(vmFiles, ouputFilename, inputIsDirectory, opts)
+	if err != nil {
+		return err
+	}
 
-	if inputIsDirectory {
-		if err := writer.WriteInit(); err != nil {
-			return fmt.Errorf("can't write the bootstrap code: %w", err)
+	if opts.eliminate {
+		removed := vmTranslator.Removed()
+		fmt.Fprintf(os.Stderr, "%s: removed %d functions\n", filepath.Base(ouputFilename), len(removed))
+		for _, function := range removed {
+			fmt.Fprintf(os.Stderr, "  %s\n", function)
 		}
 	}
 
-	for _, file := range files {
-		writer.SetFilename(file)
-
-		if err := parseVMFile(file, writer); err != nil {
-			return fmt.Errorf("can't parse %s: %w", file, err)
+	if opts.compact {
+		regular, _, err := translate(vmFiles, ouputFilename, inputIsDirectory, options{eliminate: opts.eliminate, ram: asm.RAMIgnore})
+		if err != nil {
+			return err
 		}
+
+		before, after := countInstructions(regular.Bytes()), countInstructions(translated.Bytes())
+		fmt.Fprintf(os.Stderr, "%s: %d -> %d instructions, saved %d\n", filepath.Base(ouputFilename), before, after, before-after)
 	}
 
-	return nil
-}
+	if opts.sourceMap {
+		if err := writeSourceMap(ouputFilename+".map", vmTranslator.SourceMap()); err != nil {
+			return err
+		}
+	}
 
-func parseVMFile(file string, writer *code.Writer) error {
-	f, err := os.Open(file)
+	outputFile, err := os.Create(ouputFilename)
 	if err != nil {
+		return fmt.Errorf("can't open the output file: %w", err)
+	}
+	defer outputFile.Close()
+
+	if !opts.optimize {
+		_, err := translated.WriteTo(outputFile)
 		return err
 	}
-	defer f.Close()
 
-	vmParser, err := newParser(f)
+	stats, err := asm.Optimize(translated, ouputFilename, outputFile, asm.Options{})
 	if err != nil {
-		return err
+		return fmt.Errorf("can't optimize %s: %w", ouputFilename, err)
+	}
+
+	fmt.Fprintf(os.Stderr, "%s: %s\n", filepath.Base(ouputFilename), stats)
+	return nil
+}
+
+// translate translates the .vm files into the assembly or the WebAssembly module, the bootstrap code
+// is written first if requested. Returns the translator for the report of the translation.
+func translate(files []translator.File, outputFilename string, bootstrap bool, opts options) (*bytes.Buffer, *translator.Translator, error) {
+	var translated bytes.Buffer
+	vmTranslator := translator.New(&translated, outputFilename)
+	if opts.wasm {
+		vmTranslator = translator.NewBackend(wasm.NewWriter(&translated))
+	}
+	vmTranslator.SetCompact(opts.compact)
+	vmTranslator.SetEliminate(opts.eliminate)
+	vmTranslator.SetVerify(opts.verify)
+
+	if bootstrap {
+		if err := vmTranslator.WriteInit(); err != nil {
+			return nil, nil, fmt.Errorf("can't write the bootstrap code: %w", err)
+		}
+	}
+
+	if err := vmTranslator.TranslateFiles(files); err != nil {
+		return nil, nil, err
+	}
+
+	if err := vmTranslator.Finish(); err != nil {
+		return nil, nil, err
 	}
 
-	currentFunction := ""
-	for vmParser.hasMoreCommands() {
-		vmParser.advance()
-
-		switch vmParser.commandType() {
-		case pushCmd:
-			err = writer.WritePush(vmParser.firstArgument(), vmParser.secondArgument())
-		case popCmd:
-			err = writer.WritePop(vmParser.firstArgument(), vmParser.secondArgument())
-		case labelCmd:
-			err = writer.WriteLabel(vmParser.firstArgument(), currentFunction)
-		case gotoCmd:
-			err = writer.WriteGoto(vmParser.firstArgument(), currentFunction)
-		case ifCmd:
-			err = writer.WriteIf(vmParser.firstArgument(), currentFunction)
-		case functionCmd:
-			err = writer.WriteFunction(vmParser.firstArgument(), vmParser.secondArgument())
-			currentFunction = vmParser.firstArgument()
-		case callCmd:
-			err = writer.WriteCall(vmParser.firstArgument(), vmParser.secondArgument())
-		case returnCmd:
-			err = writer.WriteReturn()
-		default:
-			err = writer.WriteArithmetic(vmParser.firstArgument())
+	if err := vmTranslator.CheckStatics(); err != nil {
+		switch opts.ram {
+		case asm.RAMError:
+			return nil, nil, err
+		case asm.RAMWarn:
+			fmt.Fprintf(os.Stderr, "warning: %s\n", err)
 		}
+	}
+
+	return &translated, vmTranslator, nil
+}
 
+// countInstructions returns the number of instructions of the assembly, labels and comments are skipped
+func countInstructions(code []byte) int {
+	count := 0
+	for _, line := range strings.Split(string(code), "\n") {
+		if i := strings.Index(line, "//"); i != -1 {
+			line = line[:i]
+		}
+
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "(") {
+			count++
+		}
+	}
+
+	return count
+}
+
+// readFiles reads the .vm files
+func readFiles(filenames []string) ([]translator.File, error) {
+	files := make([]translator.File, 0, len(filenames))
+	for _, filename := range filenames {
+		code, err := os.ReadFile(filename)
 		if err != nil {
-			return err
+			return nil, err
 		}
+
+		files = append(files, translator.File{Filename: filename, Code: code})
 	}
 
-	return nil
+	return files, nil
+}
+
+// writeSourceMap writes the source map into the file
+func writeSourceMap(filename string, sourceMap sourcemap.Map) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("can't create the source map: %w", err)
+	}
+
+	if err := sourceMap.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
 }
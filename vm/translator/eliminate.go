@@ -0,0 +1,39 @@
+package translator
+
+import (
+	"fmt"
+
+	"github.com/ProchazkaDavid/nand2tetris/vm/callgraph"
+	"github.com/ProchazkaDavid/nand2tetris/vm/command"
+)
+
+// eliminate removes the functions unreachable from the Sys.init, commands outside of functions are kept.
+// Returns the remaining commands and names of the removed functions in the order of the files.
+func eliminate(files [][]command.Command) ([][]command.Command, []string, error) {
+	graph := callgraph.New(files)
+	if !graph.Defined(callgraph.EntryFunction) {
+		return nil, nil, fmt.Errorf("can't eliminate dead functions, %s isn't defined", callgraph.EntryFunction)
+	}
+
+	reachable := graph.Reachable("", callgraph.EntryFunction)
+
+	var removed []string
+	remaining := make([][]command.Command, len(files))
+	for i, commands := range files {
+		keep := true
+		for _, cmd := range commands {
+			if cmd.Type == command.Function {
+				keep = reachable[cmd.First]
+				if !keep {
+					removed = append(removed, cmd.First)
+				}
+			}
+
+			if keep {
+				remaining[i] = append(remaining[i], cmd)
+			}
+		}
+	}
+
+	return remaining, removed, nil
+}
@@ -0,0 +1,46 @@
+package translator
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// unused is the .vm file with the functions unreachable from Sys.init
+const unused = `function Unused.f 0
+push static 0
+call Unused.g 1
+return
+function Unused.g 0
+push argument 0
+call Sys.init 0
+return
+`
+
+func TestEliminate(t *testing.T) {
+	for _, program := range programs {
+		t.Run(program.path, func(t *testing.T) {
+			files := append(readFiles(t, filepath.Join(examples, program.path)), File{Filename: "Unused.vm", Code: []byte(unused)})
+			expected, _ := run(t, files, func(*Translator) {})
+			eliminated, vmTranslator := run(t, files, func(translator *Translator) { translator.SetEliminate(true) })
+
+			compareRAM(t, eliminated, expected)
+
+			if removed := vmTranslator.Removed(); !reflect.DeepEqual(removed, []string{"Unused.f", "Unused.g"}) {
+				t.Errorf("removed %v, expected [Unused.f Unused.g]", removed)
+			}
+		})
+	}
+}
+
+func TestEliminateWithoutEntry(t *testing.T) {
+	var assembly strings.Builder
+	vmTranslator := New(&assembly, "Test.asm")
+	vmTranslator.SetEliminate(true)
+
+	err := vmTranslator.TranslateFiles([]File{{Filename: "Unused.vm", Code: []byte(unused)}})
+	if err == nil || err.Error() != "can't eliminate dead functions, Sys.init isn't defined" {
+		t.Errorf("got error %v, expected the undefined Sys.init", err)
+	}
+}
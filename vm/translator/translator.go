@@ -0,0 +1,303 @@
+// Package translator translates the VM code into the Hack assembly.
+package translator
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/ProchazkaDavid/nand2tetris/sourcemap"
+	"github.com/ProchazkaDavid/nand2tetris/vm/code"
+	"github.com/ProchazkaDavid/nand2tetris/vm/command"
+	"github.com/ProchazkaDavid/nand2tetris/vm/verifier"
+)
+
+// staticSize is the number of words of the static region 16-255 of the RAM
+const staticSize = 240
+
+// Backend writes the code of the VM commands for the target platform.
+// The code.Writer writes the Hack assembly, the wasm.Writer the WebAssembly text format.
+type Backend interface {
+	SetFilename(filename string)
+	WriteInit() error
+	WriteArithmetic(operation string) error
+	WritePush(segment string, index int) error
+	WritePop(segment string, index int) error
+	WriteLabel(label, function string) error
+	WriteGoto(label, function string) error
+	WriteIf(label, function string) error
+	WriteFunction(name string, variables int) error
+	WriteCall(function string, arguments int) error
+	WriteReturn() error
+	// Statics returns the number of the static variables
+	Statics() int
+}
+
+// lineCounter is the backend counting the written lines for the source map
+type lineCounter interface {
+	Lines() int
+}
+
+// Translator translates .vm files into a single .asm program, or the program of the other backend.
+type Translator struct {
+	backend Backend
+	// writer is the backend of the Hack assembly, nil for other backends
+	writer *code.Writer
+	// eliminate enables the dead-function elimination of TranslateFiles
+	eliminate bool
+	// verify enables the verification of TranslateFiles
+	verify bool
+	// removed contains the functions removed by the dead-function elimination
+	removed []string
+	// sourceMap maps the lines of the output to the lines of the .vm files
+	sourceMap sourcemap.Map
+}
+
+// New creates a translator writing the assembly into the output.
+// The filename of the output is used to name the static variables until SetFilename is called.
+func New(output io.StringWriter, filename string) *Translator {
+	writer := code.NewWriter(output, filename)
+	return &Translator{backend: writer, writer: writer}
+}
+
+// NewBackend creates a translator writing the code by the backend. Files are translated
+// one by one and the source map is collected only if the backend counts its lines.
+func NewBackend(backend Backend) *Translator {
+	return &Translator{backend: backend}
+}
+
+// SetCompact enables the size-optimized code with shared call, return and comparison routines.
+// The routines are written by Finish. Only the assembly has the compact code.
+func (t *Translator) SetCompact(compact bool) {
+	if t.writer != nil {
+		t.writer.SetCompact(compact)
+	}
+}
+
+// SetEliminate enables the dead-function elimination. TranslateFiles then translates only
+// the functions reachable by calls from Sys.init, so the files have to form the whole program.
+func (t *Translator) SetEliminate(eliminate bool) { t.eliminate = eliminate }
+
+// SetVerify enables the verification of the VM code by the verifier package before the translation.
+// TranslateFiles then returns the found errors as the command.ErrorList, so the files have to form
+// the whole program.
+func (t *Translator) SetVerify(verify bool) { t.verify = verify }
+
+// Removed returns the functions removed by the dead-function elimination in the order of the files.
+func (t *Translator) Removed() []string { return t.removed }
+
+// SourceMap returns the map of the lines of the output to the lines of the translated .vm files.
+// Lines of the bootstrap code and the shared routines have no source.
+func (t *Translator) SourceMap() sourcemap.Map { return t.sourceMap }
+
+// Finish writes the shared routines of the compact code, or closes the backend implementing
+// the io.Closer, e.g. writes the WebAssembly module. Should be called after all files are translated.
+func (t *Translator) Finish() error {
+	if t.writer != nil {
+		return t.writer.WriteRoutines()
+	}
+
+	if closer, ok := t.backend.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+// WriteInit writes the bootstrap code, which sets the stack pointer and calls Sys.init.
+func (t *Translator) WriteInit() error { return t.backend.WriteInit() }
+
+// CheckStatics returns an error if the static variables of the translated files
+// don't fit into the static region of the RAM.
+func (t *Translator) CheckStatics() error {
+	if statics := t.backend.Statics(); statics > staticSize {
+		return fmt.Errorf("%d static variables exceed the static region 16-255 of %d words", statics, staticSize)
+	}
+
+	return nil
+}
+
+// Translate translates the VM code read from the input. The filename of the .vm file
+// is used to name its static variables and in positions of errors. Invalid commands
+// are returned at once as the command.ErrorList.
+func (t *Translator) Translate(input io.Reader, filename string) error {
+	commands, err := command.Parse(input, filename)
+	if err != nil {
+		return err
+	}
+
+	t.backend.SetFilename(filename)
+	sourceMap, err := generate(t.backend, filename, commands)
+	t.sourceMap = append(t.sourceMap, sourceMap...)
+
+	return err
+}
+
+// File is the VM code of the .vm file.
+type File struct {
+	Filename string
+	Code     []byte
+}
+
+// TranslateFiles translates the files like Translate. The files are parsed and translated
+// in parallel goroutines and their code is written in the order of the files, so the output
+// is the same as of translating them one by one. Invalid commands of all files are returned
+// at once as the command.ErrorList.
+func (t *Translator) TranslateFiles(files []File) error {
+	parsed := make([][]command.Command, len(files))
+	errs := make([]error, len(files))
+	parallel(len(files), func(i int) {
+		parsed[i], errs[i] = command.Parse(bytes.NewReader(files[i].Code), files[i].Filename)
+	})
+
+	var errors command.ErrorList
+	for _, err := range errs {
+		if list, ok := err.(command.ErrorList); ok {
+			errors = append(errors, list...)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if err := errors.Err(); err != nil {
+		return err
+	}
+
+	if t.verify {
+		verified := make([]verifier.File, 0, len(files))
+		for i, file := range files {
+			verified = append(verified, verifier.File{Filename: file.Filename, Commands: parsed[i]})
+		}
+
+		if err := verifier.Verify(verified).Err(); err != nil {
+			return err
+		}
+	}
+
+	if t.eliminate {
+		var removed []string
+		var err error
+		if parsed, removed, err = eliminate(parsed); err != nil {
+			return err
+		}
+		t.removed = append(t.removed, removed...)
+	}
+
+	if t.writer == nil {
+		for i, commands := range parsed {
+			t.backend.SetFilename(files[i].Filename)
+			sourceMap, err := generate(t.backend, files[i].Filename, commands)
+			t.sourceMap = append(t.sourceMap, sourceMap...)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	// Labels are numbered across the whole program, so every file starts with the counters
+	// following the commands of the previous files
+	counters := make([]code.Counters, len(files))
+	next := t.writer.Counters()
+	for i, commands := range parsed {
+		counters[i] = next
+		for _, cmd := range commands {
+			next.Count(cmd)
+		}
+	}
+
+	forks := make([]*code.Writer, len(files))
+	outputs := make([]strings.Builder, len(files))
+	sourceMaps := make([]sourcemap.Map, len(files))
+	parallel(len(files), func(i int) {
+		forks[i] = t.writer.Fork(&outputs[i], files[i].Filename, counters[i])
+		sourceMaps[i], errs[i] = generate(forks[i], files[i].Filename, parsed[i])
+	})
+
+	for i, fork := range forks {
+		if errs[i] != nil {
+			return errs[i]
+		}
+
+		// Lines of the fork follow the lines of the previous files
+		for _, entry := range sourceMaps[i] {
+			entry.Target += t.writer.Lines()
+			t.sourceMap = append(t.sourceMap, entry)
+		}
+
+		if err := t.writer.Join(fork, outputs[i].String()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parallel calls the function for the indices 0 to n-1 in separate goroutines and waits for them
+func parallel(n int, function func(i int)) {
+	var wg sync.WaitGroup
+	wg.Add(n)
+
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+			function(i)
+		}(i)
+	}
+
+	wg.Wait()
+}
+
+// generate writes the code of the commands of the .vm file by the writer and returns
+// the map of the written lines to the lines of the commands, if the writer counts them
+func generate(writer Backend, filename string, commands []command.Command) (sourcemap.Map, error) {
+	var sourceMap sourcemap.Map
+	lines, _ := writer.(lineCounter)
+	currentFunction := ""
+	for _, cmd := range commands {
+		var err error
+		start := 0
+		if lines != nil {
+			start = lines.Lines()
+		}
+
+		switch cmd.Type {
+		case command.Push:
+			err = writer.WritePush(cmd.First, cmd.Second)
+		case command.Pop:
+			err = writer.WritePop(cmd.First, cmd.Second)
+		case command.Label:
+			err = writer.WriteLabel(cmd.First, currentFunction)
+		case command.Goto:
+			err = writer.WriteGoto(cmd.First, currentFunction)
+		case command.If:
+			err = writer.WriteIf(cmd.First, currentFunction)
+		case command.Function:
+			err = writer.WriteFunction(cmd.First, cmd.Second)
+			currentFunction = cmd.First
+		case command.Call:
+			err = writer.WriteCall(cmd.First, cmd.Second)
+		case command.Return:
+			err = writer.WriteReturn()
+		default:
+			err = writer.WriteArithmetic(cmd.First)
+		}
+
+		if err != nil {
+			return sourceMap, err
+		}
+
+		if lines == nil {
+			continue
+		}
+
+		for line := start + 1; line <= lines.Lines(); line++ {
+			sourceMap = append(sourceMap, sourcemap.Entry{Target: line, Source: sourcemap.Position{Filename: filename, Line: cmd.Line}})
+		}
+	}
+
+	return sourceMap, nil
+}
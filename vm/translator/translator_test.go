@@ -0,0 +1,197 @@
+package translator
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ProchazkaDavid/nand2tetris/assembler/asm"
+	"github.com/ProchazkaDavid/nand2tetris/emulator/computer"
+)
+
+// examples is the folder with the examples of the VM translator
+const examples = "../examples"
+
+// programs are the examples with Sys.init translated with the bootstrap code
+// and their results in the RAM
+var programs = []struct {
+	path    string
+	results map[int]int16
+}{
+	{"NestedCall.vm", map[int]int16{0: 261, 5: 135, 6: 246}},
+	{"FibonacciElement", map[int]int16{0: 262, 261: 3}},
+	{"StaticsTest", map[int]int16{0: 263, 261: -2, 262: 8}},
+}
+
+// readFiles reads the .vm file or the .vm files of the folder
+func readFiles(t *testing.T, path string) []File {
+	t.Helper()
+
+	filenames := []string{path}
+	if !strings.HasSuffix(path, ".vm") {
+		filenames, _ = filepath.Glob(filepath.Join(path, "*.vm"))
+	}
+
+	var files []File
+	for _, filename := range filenames {
+		code, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, File{Filename: filename, Code: code})
+	}
+
+	return files
+}
+
+// run translates the files with the bootstrap code, configured by the setup, and executes
+// the assembled program by the CPU emulator until it halts
+func run(t *testing.T, files []File, setup func(translator *Translator)) (*computer.Computer, *Translator) {
+	t.Helper()
+
+	var assembly strings.Builder
+	vmTranslator := New(&assembly, "Test.asm")
+	setup(vmTranslator)
+
+	if err := vmTranslator.WriteInit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := vmTranslator.TranslateFiles(files); err != nil {
+		t.Fatal(err)
+	}
+	if err := vmTranslator.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	program, err := asm.AssembleString(assembly.String(), "Test.asm", asm.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := computer.New()
+	if err := c.LoadWords(program.Words); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Run(100000); err != nil || !c.Halted() {
+		t.Fatalf("the program didn't halt: %v", err)
+	}
+
+	return c, vmTranslator
+}
+
+// compareRAM compares the registers, the static region and the stack of the computers.
+// The registers R13-R15 used by the generated code and the return addresses saved
+// in the frames of the call stack differ between the variants of the code.
+func compareRAM(t *testing.T, c, expected *computer.Computer) {
+	t.Helper()
+
+	sp := int(expected.RAM[0])
+	if int(c.RAM[0]) != sp {
+		t.Fatalf("SP = %d, expected %d", c.RAM[0], sp)
+	}
+
+	skipped := map[int]bool{13: true, 14: true, 15: true}
+	for frame := int(expected.RAM[1]); frame >= 261 && frame <= sp; frame = int(expected.RAM[frame-4]) {
+		skipped[frame-5] = true
+	}
+
+	for address := 0; address < sp; address++ {
+		if !skipped[address] && c.RAM[address] != expected.RAM[address] {
+			t.Errorf("RAM[%d] = %d, expected %d", address, int16(c.RAM[address]), int16(expected.RAM[address]))
+		}
+	}
+}
+
+func TestCompact(t *testing.T) {
+	for _, program := range programs {
+		t.Run(program.path, func(t *testing.T) {
+			files := readFiles(t, filepath.Join(examples, program.path))
+			expected, _ := run(t, files, func(*Translator) {})
+			for address, value := range program.results {
+				if int16(expected.RAM[address]) != value {
+					t.Errorf("RAM[%d] = %d, expected %d", address, int16(expected.RAM[address]), value)
+				}
+			}
+
+			compact, _ := run(t, files, func(translator *Translator) { translator.SetCompact(true) })
+
+			compareRAM(t, compact, expected)
+		})
+	}
+}
+
+// classes returns the .vm files of the classes using the comparisons, calls and static variables,
+// which are numbered across the whole program
+func classes(count int) []File {
+	var files []File
+	for i := 0; i < count; i++ {
+		code := fmt.Sprintf(`function Class%[1]d.f 1
+push argument 0
+push static %[1]d
+lt
+if-goto LESS
+push constant %[1]d
+call Class%[1]d.g 1
+return
+label LESS
+push argument 0
+push constant 1
+eq
+return
+function Class%[1]d.g 0
+push argument 0
+pop static 0
+push argument 0
+push constant 0
+gt
+return
+`, i)
+		files = append(files, File{Filename: fmt.Sprintf("Class%d.vm", i), Code: []byte(code)})
+	}
+
+	return files
+}
+
+// TestTranslateFilesDeterministic compares the output of the parallel translation
+// with the translation of the files one by one
+func TestTranslateFilesDeterministic(t *testing.T) {
+	files := append(classes(16), readFiles(t, filepath.Join(examples, "StaticsTest"))...)
+
+	for _, compact := range []bool{false, true} {
+		var expected strings.Builder
+		sequential := New(&expected, "Test.asm")
+		sequential.SetCompact(compact)
+		for _, file := range files {
+			if err := sequential.Translate(bytes.NewReader(file.Code), file.Filename); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := sequential.Finish(); err != nil {
+			t.Fatal(err)
+		}
+
+		for run := 0; run < 10; run++ {
+			var output strings.Builder
+			parallel := New(&output, "Test.asm")
+			parallel.SetCompact(compact)
+			if err := parallel.TranslateFiles(files); err != nil {
+				t.Fatal(err)
+			}
+			if err := parallel.Finish(); err != nil {
+				t.Fatal(err)
+			}
+
+			if output.String() != expected.String() {
+				t.Fatalf("compact %v, run %d: the parallel translation differs from the sequential one", compact, run)
+			}
+
+			if !reflect.DeepEqual(parallel.SourceMap(), sequential.SourceMap()) {
+				t.Fatalf("compact %v, run %d: the source map differs from the sequential one", compact, run)
+			}
+		}
+	}
+}
@@ -0,0 +1,310 @@
+// Package verifier finds errors of the VM code, which would crash the program at runtime.
+package verifier
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ProchazkaDavid/nand2tetris/vm/command"
+)
+
+// File is the parsed .vm file.
+type File struct {
+	Filename string
+	Commands []command.Command
+}
+
+// stackEffects contains the number of values popped and pushed by the arithmetic commands
+var stackEffects = map[string][2]int{
+	"add": {2, 1},
+	"sub": {2, 1},
+	"neg": {1, 1},
+	"eq":  {2, 1},
+	"gt":  {2, 1},
+	"lt":  {2, 1},
+	"and": {2, 1},
+	"or":  {2, 1},
+	"not": {1, 1},
+}
+
+// Verify checks the files, which form the whole program. Reports
+//   - stack underflow on any control-flow path within the function
+//   - labels reached with different stack depths
+//   - goto and if-goto to labels not defined in the same function
+//   - calls of undefined functions
+//   - calls with the number of arguments different from other calls of the function
+//
+// The errors are sorted by the files and lines.
+func Verify(files []File) command.ErrorList { return VerifyExternal(files, nil) }
+
+// VerifyExternal checks the files like Verify, except the calls of the external functions, which are
+// defined outside of the files, e.g. the OS functions. Their calls aren't reported as undefined,
+// but they still have to use the same number of arguments.
+func VerifyExternal(files []File, external map[string]bool) command.ErrorList {
+	var errors command.ErrorList
+
+	defined := map[string]bool{}
+	for _, file := range files {
+		for _, cmd := range file.Commands {
+			if cmd.Type == command.Function {
+				defined[cmd.First] = true
+			}
+		}
+	}
+
+	for _, file := range files {
+		for _, function := range splitFunctions(file) {
+			errors = append(errors, function.verify()...)
+		}
+	}
+
+	errors = append(errors, verifyCalls(files, defined, external)...)
+
+	order := map[string]int{}
+	for i, file := range files {
+		order[file.Filename] = i
+	}
+
+	sort.SliceStable(errors, func(i, j int) bool {
+		a, b := errors[i], errors[j]
+		if a.Filename != b.Filename {
+			return order[a.Filename] < order[b.Filename]
+		}
+		return a.Line < b.Line
+	})
+
+	return errors
+}
+
+// function is the body of the function, commands outside of functions form the function without the name
+type function struct {
+	filename string
+	name     string
+	commands []command.Command
+}
+
+// splitFunctions returns the functions of the file
+func splitFunctions(file File) []function {
+	current := function{filename: file.Filename}
+	var functions []function
+
+	for _, cmd := range file.Commands {
+		if cmd.Type == command.Function {
+			if current.name != "" || len(current.commands) > 0 {
+				functions = append(functions, current)
+			}
+			current = function{filename: file.Filename, name: cmd.First}
+			continue
+		}
+
+		current.commands = append(current.commands, cmd)
+	}
+
+	if current.name != "" || len(current.commands) > 0 {
+		functions = append(functions, current)
+	}
+
+	return functions
+}
+
+// errorf returns the error at the line of the command
+func (f function) errorf(cmd command.Command, format string, args ...interface{}) *command.Error {
+	return &command.Error{Filename: f.filename, Line: cmd.Line, Message: fmt.Sprintf(format, args...)}
+}
+
+// verify checks the labels and the stack depth along every control-flow path of the function
+func (f function) verify() command.ErrorList {
+	var errors command.ErrorList
+
+	labels := map[string]int{}
+	for i, cmd := range f.commands {
+		if cmd.Type != command.Label {
+			continue
+		}
+
+		if _, ok := labels[cmd.First]; ok {
+			errors = append(errors, f.errorf(cmd, "label %s is already defined in %s", cmd.First, f.displayName()))
+			continue
+		}
+		labels[cmd.First] = i
+	}
+
+	for _, cmd := range f.commands {
+		if cmd.Type == command.Goto || cmd.Type == command.If {
+			if _, ok := labels[cmd.First]; !ok {
+				errors = append(errors, f.errorf(cmd, "label %s isn't defined in %s", cmd.First, f.displayName()))
+			}
+		}
+	}
+
+	return append(errors, f.verifyStack(labels)...)
+}
+
+// displayName returns the name of the function used in the errors
+func (f function) displayName() string {
+	if f.name == "" {
+		return "the code outside of functions"
+	}
+
+	return "function " + f.name
+}
+
+// verifyStack computes the stack depth of every command reachable from the start of the function.
+// The depth is relative to the stack after the local variables. Every command is verified once,
+// with the depth of the first path reaching it.
+func (f function) verifyStack(labels map[string]int) command.ErrorList {
+	var errors command.ErrorList
+
+	depths := make([]int, len(f.commands))
+	for i := range depths {
+		depths[i] = -1
+	}
+
+	// reach records the depth of the command at the index reached by the jump from the command
+	var worklist []int
+	reach := func(from command.Command, index, depth int) {
+		if index >= len(f.commands) {
+			return
+		}
+
+		switch {
+		case depths[index] == -1:
+			depths[index] = depth
+			worklist = append(worklist, index)
+		case depths[index] != depth && f.commands[index].Type == command.Label:
+			errors = append(errors, f.errorf(from, "stack depth %d differs from depth %d at label %s",
+				depth, depths[index], f.commands[index].First))
+		}
+	}
+
+	reach(command.Command{}, 0, 0)
+	for len(worklist) > 0 {
+		index := worklist[len(worklist)-1]
+		worklist = worklist[:len(worklist)-1]
+
+		cmd := f.commands[index]
+		popped, pushed := effect(cmd)
+
+		depth := depths[index]
+		if depth < popped {
+			errors = append(errors, f.errorf(cmd, "stack underflow, %s needs %s, stack has %d", describe(cmd), values(popped), depth))
+			// Continues as if the missing values were there, so the underflow is reported once
+			depth = popped
+		}
+		depth += pushed - popped
+
+		switch cmd.Type {
+		case command.Return:
+		case command.Goto:
+			if target, ok := labels[cmd.First]; ok {
+				reach(cmd, target, depth)
+			}
+		case command.If:
+			if target, ok := labels[cmd.First]; ok {
+				reach(cmd, target, depth)
+			}
+			reach(cmd, index+1, depth)
+		default:
+			reach(cmd, index+1, depth)
+		}
+	}
+
+	return errors
+}
+
+// effect returns the number of values popped and pushed by the command
+func effect(cmd command.Command) (popped, pushed int) {
+	switch cmd.Type {
+	case command.Push:
+		return 0, 1
+	case command.Pop, command.If:
+		return 1, 0
+	case command.Call:
+		return cmd.Second, 1
+	case command.Return:
+		return 1, 0
+	case command.Arithmetic:
+		effect := stackEffects[cmd.First]
+		return effect[0], effect[1]
+	default:
+		return 0, 0
+	}
+}
+
+// values returns the number of values with the noun
+func values(n int) string {
+	if n == 1 {
+		return "1 value"
+	}
+
+	return fmt.Sprintf("%d values", n)
+}
+
+// describe returns the name of the command used in the errors
+func describe(cmd command.Command) string {
+	switch cmd.Type {
+	case command.Push:
+		return "push"
+	case command.Pop:
+		return "pop"
+	case command.If:
+		return "if-goto"
+	case command.Call:
+		return "call " + cmd.First
+	case command.Return:
+		return "return"
+	default:
+		return cmd.First
+	}
+}
+
+// verifyCalls reports calls of undefined functions, which aren't external, and calls with the number
+// of arguments different from the most common number of arguments of the function
+func verifyCalls(files []File, defined, external map[string]bool) command.ErrorList {
+	var errors command.ErrorList
+
+	type call struct {
+		filename string
+		cmd      command.Command
+	}
+
+	calls := map[string][]call{}
+	var functions []string
+	for _, file := range files {
+		for _, cmd := range file.Commands {
+			if cmd.Type != command.Call {
+				continue
+			}
+
+			if !defined[cmd.First] && !external[cmd.First] {
+				errors = append(errors, &command.Error{Filename: file.Filename, Line: cmd.Line, Message: fmt.Sprintf("function %s isn't defined", cmd.First)})
+				continue
+			}
+
+			if _, ok := calls[cmd.First]; !ok {
+				functions = append(functions, cmd.First)
+			}
+			calls[cmd.First] = append(calls[cmd.First], call{file.Filename, cmd})
+		}
+	}
+
+	for _, name := range functions {
+		counts := map[int]int{}
+		common := calls[name][0].cmd.Second
+		for _, c := range calls[name] {
+			counts[c.cmd.Second]++
+			if counts[c.cmd.Second] > counts[common] {
+				common = c.cmd.Second
+			}
+		}
+
+		for _, c := range calls[name] {
+			if c.cmd.Second != common {
+				errors = append(errors, &command.Error{Filename: c.filename, Line: c.cmd.Line,
+					Message: fmt.Sprintf("call %s with %d arguments, other calls use %d", name, c.cmd.Second, common)})
+			}
+		}
+	}
+
+	return errors
+}
@@ -0,0 +1,146 @@
+package verifier
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ProchazkaDavid/nand2tetris/vm/command"
+)
+
+// parse parses the files given by their names and the code
+func parse(t *testing.T, files ...string) []File {
+	t.Helper()
+
+	var parsed []File
+	for i := 0; i < len(files); i += 2 {
+		commands, err := command.Parse(strings.NewReader(files[i+1]), files[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed = append(parsed, File{Filename: files[i], Commands: commands})
+	}
+
+	return parsed
+}
+
+// messages returns the errors as strings
+func messages(errors command.ErrorList) []string {
+	var messages []string
+	for _, err := range errors {
+		messages = append(messages, err.Error())
+	}
+
+	return messages
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		code   string
+		errors []string
+	}{
+		{
+			name: "valid function",
+			code: "function Main.max 0\npush argument 0\npush argument 1\ngt\nif-goto FIRST\npush argument 1\nreturn\nlabel FIRST\npush argument 0\nreturn\n",
+		},
+		{
+			name:   "stack underflow",
+			code:   "function Main.main 0\npush constant 1\nadd\nreturn\n",
+			errors: []string{"Main.vm:3: stack underflow, add needs 2 values, stack has 1"},
+		},
+		{
+			name:   "underflow of return",
+			code:   "function Main.main 0\nreturn\n",
+			errors: []string{"Main.vm:2: stack underflow, return needs 1 value, stack has 0"},
+		},
+		{
+			name:   "different depths at label",
+			code:   "function Main.main 0\npush constant 0\nif-goto END\npush constant 1\nlabel END\npush constant 0\nreturn\n",
+			errors: []string{"Main.vm:4: stack depth 1 differs from depth 0 at label END"},
+		},
+		{
+			name:   "undefined label",
+			code:   "function Main.main 0\ngoto NOPE\n",
+			errors: []string{"Main.vm:2: label NOPE isn't defined in function Main.main"},
+		},
+		{
+			name:   "label of other function",
+			code:   "function Main.a 0\nlabel L\npush constant 0\nreturn\nfunction Main.b 0\ngoto L\n",
+			errors: []string{"Main.vm:6: label L isn't defined in function Main.b"},
+		},
+		{
+			name:   "duplicate label",
+			code:   "label L\nlabel L\ngoto L\n",
+			errors: []string{"Main.vm:2: label L is already defined in the code outside of functions"},
+		},
+		{
+			name:   "undefined function",
+			code:   "function Main.main 0\ncall Main.missing 0\nreturn\n",
+			errors: []string{"Main.vm:2: function Main.missing isn't defined"},
+		},
+		{
+			name:   "different numbers of arguments",
+			code:   "function Main.f 0\npush constant 0\nreturn\nfunction Main.main 0\npush constant 1\ncall Main.f 1\npush constant 1\ncall Main.f 1\npush constant 1\npush constant 2\ncall Main.f 2\nreturn\n",
+			errors: []string{"Main.vm:11: call Main.f with 2 arguments, other calls use 1"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if errors := messages(Verify(parse(t, "Main.vm", test.code))); !reflect.DeepEqual(errors, test.errors) {
+				t.Errorf("got errors %q, expected %q", errors, test.errors)
+			}
+		})
+	}
+}
+
+func TestVerifyOrder(t *testing.T) {
+	files := parse(t,
+		"Main.vm", "function Main.main 0\ncall Lib.f 0\nadd\ngoto END\n",
+		"Lib.vm", "function Lib.g 0\nnot\nreturn\n",
+	)
+
+	expected := []string{
+		"Main.vm:2: function Lib.f isn't defined",
+		"Main.vm:3: stack underflow, add needs 2 values, stack has 1",
+		"Main.vm:4: label END isn't defined in function Main.main",
+		"Lib.vm:2: stack underflow, not needs 1 value, stack has 0",
+	}
+	if errors := messages(Verify(files)); !reflect.DeepEqual(errors, expected) {
+		t.Errorf("got errors %q, expected %q", errors, expected)
+	}
+}
+
+func TestVerifyExternal(t *testing.T) {
+	files := parse(t, "Main.vm", "function Main.main 0\npush constant 1\ncall Math.abs 1\npush constant 1\npush constant 2\ncall Math.abs 2\ncall Main.missing 0\nreturn\n")
+
+	expected := []string{
+		"Main.vm:6: call Math.abs with 2 arguments, other calls use 1",
+		"Main.vm:7: function Main.missing isn't defined",
+	}
+	if errors := messages(VerifyExternal(files, map[string]bool{"Math.abs": true})); !reflect.DeepEqual(errors, expected) {
+		t.Errorf("got errors %q, expected %q", errors, expected)
+	}
+}
+
+func TestVerifyExamples(t *testing.T) {
+	for _, example := range []string{"FibonacciElement", "StaticsTest"} {
+		filenames, _ := filepath.Glob(filepath.Join("../examples", example, "*.vm"))
+
+		var files []string
+		for _, filename := range filenames {
+			code, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			files = append(files, filename, string(code))
+		}
+
+		if errors := Verify(parse(t, files...)); len(errors) > 0 {
+			t.Errorf("%s: %v", example, errors)
+		}
+	}
+}
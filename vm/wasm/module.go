@@ -0,0 +1,239 @@
+package wasm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// runtime contains the helper functions of the generated code. Names of the helpers
+// start with "$$", which can't be a part of names of the VM functions.
+const runtime = `  ;; RAM[address]
+  (func $$get (param $address i32) (result i32)
+    (i32.load16_u (i32.shl (local.get $address) (i32.const 1))))
+  ;; RAM[address] = value, the value is truncated to 16 bits
+  (func $$set (param $address i32) (param $value i32)
+    (i32.store16 (i32.shl (local.get $address) (i32.const 1)) (local.get $value)))
+  (func $$push (param $value i32)
+    (call $$set (call $$get (i32.const 0)) (local.get $value))
+    (call $$set (i32.const 0) (i32.add (call $$get (i32.const 0)) (i32.const 1))))
+  (func $$pop (result i32)
+    (call $$set (i32.const 0) (i32.sub (call $$get (i32.const 0)) (i32.const 1)))
+    (call $$get (call $$get (i32.const 0))))
+  (func $$drop (param $n i32)
+    (call $$set (i32.const 0) (i32.sub (call $$get (i32.const 0)) (local.get $n))))
+  ;; sign extension of the 16-bit value
+  (func $$signed (param $value i32) (result i32)
+    (i32.shr_s (i32.shl (local.get $value) (i32.const 16)) (i32.const 16)))
+  ;; i-th of the n arguments on the top of the stack
+  (func $$argument (param $n i32) (param $i i32) (result i32)
+    (call $$signed (call $$get (i32.add (i32.sub (call $$get (i32.const 0)) (local.get $n)) (local.get $i)))))
+  ;; pushes n local variables set to 0
+  (func $$locals (param $n i32)
+    (block $done
+      (loop $next
+        (br_if $done (i32.eqz (local.get $n)))
+        (call $$push (i32.const 0))
+        (local.set $n (i32.sub (local.get $n) (i32.const 1)))
+        (br $next))))
+  ;; saves the frame of the caller with the return address 0 and sets ARG and LCL of the function with n arguments
+  (func $$call (param $n i32)
+    (call $$push (i32.const 0))
+    (call $$push (call $$get (i32.const 1)))
+    (call $$push (call $$get (i32.const 2)))
+    (call $$push (call $$get (i32.const 3)))
+    (call $$push (call $$get (i32.const 4)))
+    (call $$set (i32.const 2) (i32.sub (call $$get (i32.const 0)) (i32.add (local.get $n) (i32.const 5))))
+    (call $$set (i32.const 1) (call $$get (i32.const 0))))
+  ;; moves the returned value to ARG[0] and restores the frame of the caller
+  (func $$return (local $frame i32)
+    (local.set $frame (call $$get (i32.const 1)))
+    (call $$set (call $$get (i32.const 2)) (call $$pop))
+    (call $$set (i32.const 0) (i32.add (call $$get (i32.const 2)) (i32.const 1)))
+    (call $$set (i32.const 4) (call $$get (i32.sub (local.get $frame) (i32.const 1))))
+    (call $$set (i32.const 3) (call $$get (i32.sub (local.get $frame) (i32.const 2))))
+    (call $$set (i32.const 2) (call $$get (i32.sub (local.get $frame) (i32.const 3))))
+    (call $$set (i32.const 1) (call $$get (i32.sub (local.get $frame) (i32.const 4)))))
+`
+
+// Close writes the module. Calls of functions, which aren't defined, are imported,
+// so their calls must have the same number of arguments. Jumps must be to labels
+// of the same function.
+func (w *Writer) Close() error {
+	defined := map[string]bool{}
+	for _, f := range w.functions {
+		defined[f.name] = true
+	}
+
+	var builder strings.Builder
+	builder.WriteString("(module\n")
+
+	if w.halts {
+		builder.WriteString("  (import \"host\" \"halt\" (func $$halt))\n")
+	}
+
+	if err := w.writeImports(&builder, defined); err != nil {
+		return err
+	}
+
+	builder.WriteString("  (memory (export \"memory\") 1)\n")
+	builder.WriteString(runtime)
+
+	for _, f := range append([]*function{w.main}, w.functions...) {
+		if err := f.write(&builder, defined); err != nil {
+			return err
+		}
+	}
+
+	builder.WriteString(")\n")
+
+	_, err := w.output.WriteString(builder.String())
+	return err
+}
+
+// writeImports writes the imports of the called functions, which aren't defined, in the order of their first call
+func (w *Writer) writeImports(builder *strings.Builder, defined map[string]bool) error {
+	imported := map[string]bool{}
+
+	for _, f := range append([]*function{w.main}, w.functions...) {
+		for _, b := range f.blocks {
+			for _, inst := range b.instructions {
+				if inst.call == "" || defined[inst.call] || imported[inst.call] {
+					continue
+				}
+				imported[inst.call] = true
+
+				for _, arguments := range w.arguments[inst.call] {
+					if arguments != inst.arguments {
+						return fmt.Errorf("imported function %s is called with %d and %d arguments", inst.call, inst.arguments, arguments)
+					}
+				}
+
+				params := strings.Repeat(" i32", inst.arguments)
+				if params != "" {
+					params = " (param" + params + ")"
+				}
+
+				fmt.Fprintf(builder, "  (import \"jack\" %q (func $%s%s (result i32)))\n", inst.call, inst.call, params)
+			}
+		}
+	}
+
+	return nil
+}
+
+// write writes the function. The function with labels is a loop dispatching to its blocks
+// by the $pc local variable, jumps set the $pc and restart the loop.
+func (f *function) write(builder *strings.Builder, defined map[string]bool) error {
+	if f.name == "" {
+		builder.WriteString("  (func $$main (export \"main\")\n")
+	} else {
+		fmt.Fprintf(builder, "  ;; function %s %d\n  (func $%s\n", f.name, f.variables, f.name)
+	}
+	builder.WriteString("    (local $x i32) (local $y i32) (local $pc i32)\n")
+
+	if f.variables > 0 {
+		fmt.Fprintf(builder, "    (call $$locals (i32.const %d))\n", f.variables)
+	}
+
+	if len(f.blocks) == 1 {
+		if err := f.writeBlock(builder, f.blocks[0], 2, defined); err != nil {
+			return err
+		}
+
+		builder.WriteString("  )\n")
+		return nil
+	}
+
+	last := len(f.blocks) - 1
+	builder.WriteString("    (loop $dispatch\n")
+	for i := last; i >= 0; i-- {
+		fmt.Fprintf(builder, "%s(block $b%d\n", indent(3+last-i), i)
+	}
+
+	targets := make([]string, 0, len(f.blocks))
+	for i := range f.blocks {
+		targets = append(targets, fmt.Sprintf("$b%d", i))
+	}
+	fmt.Fprintf(builder, "%s(br_table %s (local.get $pc)))\n", indent(4+last), strings.Join(targets, " "))
+
+	for i, b := range f.blocks {
+		// The block i ends before its code, so the br_table jumps to the code
+		if i > 0 {
+			fmt.Fprintf(builder, "%s)\n", indent(3+last-i))
+		}
+
+		level := 3 + last - i
+		if b.label != "" {
+			fmt.Fprintf(builder, "%s;; label %s\n", indent(level), b.label)
+		}
+
+		if err := f.writeBlock(builder, b, level, defined); err != nil {
+			return err
+		}
+	}
+
+	builder.WriteString("    )\n  )\n")
+	return nil
+}
+
+// writeBlock writes the instructions of the block at the indentation level
+func (f *function) writeBlock(builder *strings.Builder, b *block, level int, defined map[string]bool) error {
+	for _, inst := range b.instructions {
+		text, err := f.resolve(inst, defined)
+		if err != nil {
+			return err
+		}
+
+		for _, line := range strings.Split(text, "\n") {
+			fmt.Fprintf(builder, "%s%s\n", indent(level), line)
+		}
+	}
+
+	return nil
+}
+
+// resolve returns the code of the instruction with the jump to the block of the label,
+// or with the call of the defined or imported function
+func (f *function) resolve(inst instruction, defined map[string]bool) (string, error) {
+	switch {
+	case inst.jump != "":
+		index, ok := f.labels[inst.jump]
+		if !ok {
+			return "", fmt.Errorf("label %s isn't defined in %s", inst.jump, f.displayName())
+		}
+
+		jump := fmt.Sprintf("(local.set $pc (i32.const %d))\n(br $dispatch)", index)
+		if inst.conditional {
+			return fmt.Sprintf(";; if-goto %s\n(if (call $$pop)\n  (then\n    %s))", inst.jump, strings.ReplaceAll(jump, "\n", "\n    ")), nil
+		}
+
+		return fmt.Sprintf(";; goto %s\n%s", inst.jump, jump), nil
+
+	case inst.call != "" && defined[inst.call]:
+		return fmt.Sprintf(";; call %s %d\n(call $$call (i32.const %d))\n(call $%s)", inst.call, inst.arguments, inst.arguments, inst.call), nil
+
+	case inst.call != "":
+		arguments := make([]string, 0, inst.arguments)
+		for i := 0; i < inst.arguments; i++ {
+			arguments = append(arguments, fmt.Sprintf(" (call $$argument (i32.const %d) (i32.const %d))", inst.arguments, i))
+		}
+
+		return fmt.Sprintf(";; call %s %d\n(local.set $y (call $%s%s))\n(call $$drop (i32.const %d))\n(call $$push (local.get $y))",
+			inst.call, inst.arguments, inst.call, strings.Join(arguments, ""), inst.arguments), nil
+
+	default:
+		return inst.text, nil
+	}
+}
+
+// displayName returns the name of the function used in the errors
+func (f *function) displayName() string {
+	if f.name == "" {
+		return "the code outside of functions"
+	}
+
+	return "function " + f.name
+}
+
+// indent returns the indentation of the level
+func indent(level int) string { return strings.Repeat("  ", level) }
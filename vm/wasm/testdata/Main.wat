@@ -0,0 +1,130 @@
+(module
+  (import "host" "halt" (func $$halt))
+  (import "jack" "Output.printInt" (func $Output.printInt (param i32) (result i32)))
+  (memory (export "memory") 1)
+  ;; RAM[address]
+  (func $$get (param $address i32) (result i32)
+    (i32.load16_u (i32.shl (local.get $address) (i32.const 1))))
+  ;; RAM[address] = value, the value is truncated to 16 bits
+  (func $$set (param $address i32) (param $value i32)
+    (i32.store16 (i32.shl (local.get $address) (i32.const 1)) (local.get $value)))
+  (func $$push (param $value i32)
+    (call $$set (call $$get (i32.const 0)) (local.get $value))
+    (call $$set (i32.const 0) (i32.add (call $$get (i32.const 0)) (i32.const 1))))
+  (func $$pop (result i32)
+    (call $$set (i32.const 0) (i32.sub (call $$get (i32.const 0)) (i32.const 1)))
+    (call $$get (call $$get (i32.const 0))))
+  (func $$drop (param $n i32)
+    (call $$set (i32.const 0) (i32.sub (call $$get (i32.const 0)) (local.get $n))))
+  ;; sign extension of the 16-bit value
+  (func $$signed (param $value i32) (result i32)
+    (i32.shr_s (i32.shl (local.get $value) (i32.const 16)) (i32.const 16)))
+  ;; i-th of the n arguments on the top of the stack
+  (func $$argument (param $n i32) (param $i i32) (result i32)
+    (call $$signed (call $$get (i32.add (i32.sub (call $$get (i32.const 0)) (local.get $n)) (local.get $i)))))
+  ;; pushes n local variables set to 0
+  (func $$locals (param $n i32)
+    (block $done
+      (loop $next
+        (br_if $done (i32.eqz (local.get $n)))
+        (call $$push (i32.const 0))
+        (local.set $n (i32.sub (local.get $n) (i32.const 1)))
+        (br $next))))
+  ;; saves the frame of the caller with the return address 0 and sets ARG and LCL of the function with n arguments
+  (func $$call (param $n i32)
+    (call $$push (i32.const 0))
+    (call $$push (call $$get (i32.const 1)))
+    (call $$push (call $$get (i32.const 2)))
+    (call $$push (call $$get (i32.const 3)))
+    (call $$push (call $$get (i32.const 4)))
+    (call $$set (i32.const 2) (i32.sub (call $$get (i32.const 0)) (i32.add (local.get $n) (i32.const 5))))
+    (call $$set (i32.const 1) (call $$get (i32.const 0))))
+  ;; moves the returned value to ARG[0] and restores the frame of the caller
+  (func $$return (local $frame i32)
+    (local.set $frame (call $$get (i32.const 1)))
+    (call $$set (call $$get (i32.const 2)) (call $$pop))
+    (call $$set (i32.const 0) (i32.add (call $$get (i32.const 2)) (i32.const 1)))
+    (call $$set (i32.const 4) (call $$get (i32.sub (local.get $frame) (i32.const 1))))
+    (call $$set (i32.const 3) (call $$get (i32.sub (local.get $frame) (i32.const 2))))
+    (call $$set (i32.const 2) (call $$get (i32.sub (local.get $frame) (i32.const 3))))
+    (call $$set (i32.const 1) (call $$get (i32.sub (local.get $frame) (i32.const 4)))))
+  (func $$main (export "main")
+    (local $x i32) (local $y i32) (local $pc i32)
+    (call $$set (i32.const 0) (i32.const 256))
+    ;; call Sys.init 0
+    (call $$call (i32.const 0))
+    (call $Sys.init)
+  )
+  ;; function Sys.init 0
+  (func $Sys.init
+    (local $x i32) (local $y i32) (local $pc i32)
+    (loop $dispatch
+      (block $b1
+        (block $b0
+          (br_table $b0 $b1 (local.get $pc)))
+        ;; push constant 7
+        (call $$push (i32.const 7))
+        ;; push constant 3
+        (call $$push (i32.const 3))
+        ;; call Main.max 2
+        (call $$call (i32.const 2))
+        (call $Main.max)
+        ;; call Output.printInt 1
+        (local.set $y (call $Output.printInt (call $$argument (i32.const 1) (i32.const 0))))
+        (call $$drop (i32.const 1))
+        (call $$push (local.get $y))
+        ;; pop temp 0
+        (call $$set (i32.const 5) (call $$pop))
+      )
+      ;; label HALT
+      ;; goto HALT
+      (call $$halt)
+      (unreachable)
+    )
+  )
+  ;; function Main.max 1
+  (func $Main.max
+    (local $x i32) (local $y i32) (local $pc i32)
+    (call $$locals (i32.const 1))
+    (loop $dispatch
+      (block $b1
+        (block $b0
+          (br_table $b0 $b1 (local.get $pc)))
+        ;; push argument 0
+        (call $$push (call $$get (i32.add (call $$get (i32.const 2)) (i32.const 0))))
+        ;; push argument 1
+        (call $$push (call $$get (i32.add (call $$get (i32.const 2)) (i32.const 1))))
+        ;; gt
+        (local.set $y (call $$pop))
+        (local.set $x (call $$pop))
+        (call $$push (i32.sub (i32.const 0) (i32.gt_s (call $$signed (local.get $x)) (call $$signed (local.get $y)))))
+        ;; if-goto FIRST
+        (if (call $$pop)
+          (then
+            (local.set $pc (i32.const 1))
+            (br $dispatch)))
+        ;; push argument 1
+        (call $$push (call $$get (i32.add (call $$get (i32.const 2)) (i32.const 1))))
+        ;; return
+        (call $$return)
+        (return)
+      )
+      ;; label FIRST
+      ;; push argument 0
+      (call $$push (call $$get (i32.add (call $$get (i32.const 2)) (i32.const 0))))
+      ;; pop local 0
+      (call $$set (i32.add (call $$get (i32.const 1)) (i32.const 0)) (call $$pop))
+      ;; push local 0
+      (call $$push (call $$get (i32.add (call $$get (i32.const 1)) (i32.const 0))))
+      ;; push static 0
+      (call $$push (call $$get (i32.const 16)))
+      ;; add
+      (local.set $y (call $$pop))
+      (local.set $x (call $$pop))
+      (call $$push (i32.add (local.get $x) (local.get $y)))
+      ;; return
+      (call $$return)
+      (return)
+    )
+  )
+)
@@ -0,0 +1,270 @@
+// Package wasm translates the VM commands into the WebAssembly text format.
+//
+// The Hack RAM is the exported linear memory "memory" of 16-bit little-endian words,
+// RAM[address] is at the byte offset 2*address, so the screen and the keyboard are
+// the memory regions at 16384 and 24576. The VM stack, the segments and the call frames
+// are in the memory like in the code of the code package, except the return address,
+// which is always 0. VM functions become WebAssembly functions without parameters,
+// the call pushes the frame and calls the function, the return restores the frame.
+// Called functions, which aren't defined, are imported from the "jack" module with
+// the signed arguments as parameters and the returned value as the result,
+// e.g. (import "jack" "Math.multiply" (func (param i32 i32) (result i32))).
+// Halt loops (label X, goto X) call the imported "host" "halt" function.
+package wasm
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// firstStatic is the address of the first static variable
+const firstStatic = 16
+
+// segments maps the segment to the address of its base pointer
+var segments = map[string]int{
+	"local":    1,
+	"argument": 2,
+	"this":     3,
+	"that":     4,
+}
+
+// fixedSegments maps the fixed segments to their addresses and sizes
+var fixedSegments = map[string][2]int{
+	"pointer": {3, 2},
+	"temp":    {5, 8},
+}
+
+// binaryOperations maps the VM operations to the WebAssembly instructions computing
+// the result from the operands $x and $y
+var binaryOperations = map[string]string{
+	"add": "(i32.add (local.get $x) (local.get $y))",
+	"sub": "(i32.sub (local.get $x) (local.get $y))",
+	"and": "(i32.and (local.get $x) (local.get $y))",
+	"or":  "(i32.or (local.get $x) (local.get $y))",
+	// Comparisons push -1 for true and 0 for false
+	"eq": "(i32.sub (i32.const 0) (i32.eq (local.get $x) (local.get $y)))",
+	"gt": "(i32.sub (i32.const 0) (i32.gt_s (call $$signed (local.get $x)) (call $$signed (local.get $y))))",
+	"lt": "(i32.sub (i32.const 0) (i32.lt_s (call $$signed (local.get $x)) (call $$signed (local.get $y))))",
+}
+
+// unaryOperations maps the VM operations to the WebAssembly instructions computing the result from the operand $y
+var unaryOperations = map[string]string{
+	"neg": "(i32.sub (i32.const 0) (local.get $y))",
+	"not": "(i32.xor (local.get $y) (i32.const -1))",
+}
+
+// instruction is the WebAssembly code of the VM command. Jumps and calls are resolved
+// when the whole program is known.
+type instruction struct {
+	text string
+	// jump is the label of the goto or if-goto
+	jump        string
+	conditional bool
+	// call is the called function
+	call      string
+	arguments int
+}
+
+// block is the code following the label, the first block of the function has no label
+type block struct {
+	label        string
+	instructions []instruction
+}
+
+// function is the WebAssembly function of the VM function
+type function struct {
+	name      string
+	variables int
+	blocks    []*block
+	// labels maps the labels to the indices of their blocks
+	labels map[string]int
+}
+
+// newFunction creates the function with the empty first block
+func newFunction(name string, variables int) *function {
+	return &function{name: name, variables: variables, blocks: []*block{{}}, labels: map[string]int{}}
+}
+
+// add adds the instruction to the last block
+func (f *function) add(inst instruction) {
+	last := f.blocks[len(f.blocks)-1]
+	last.instructions = append(last.instructions, inst)
+}
+
+// Writer writes the WebAssembly module of the VM commands. The commands are collected
+// and the module is written by Close.
+type Writer struct {
+	output   io.StringWriter
+	filename string
+	// main contains the bootstrap code and the commands outside of functions
+	main      *function
+	functions []*function
+	current   *function
+	// statics maps the static variables to their addresses in the order of the first use
+	statics map[string]int
+	// arguments contains the numbers of arguments of the calls of every function
+	arguments map[string][]int
+	halts     bool
+}
+
+// NewWriter creates the writer of the module into the output.
+func NewWriter(output io.StringWriter) *Writer {
+	main := newFunction("", 0)
+
+	return &Writer{
+		output:    output,
+		main:      main,
+		current:   main,
+		statics:   map[string]int{},
+		arguments: map[string][]int{},
+	}
+}
+
+// Statics returns the number of the static variables.
+func (w *Writer) Statics() int { return len(w.statics) }
+
+// SetFilename sets the filename of the following commands, which names the static variables.
+func (w *Writer) SetFilename(filename string) {
+	w.filename = strings.TrimSuffix(path.Base(filename), filepath.Ext(filename))
+	// Commands outside of functions of every file are added to the main function
+	w.current = w.main
+}
+
+// WriteInit writes the bootstrap code, which sets the stack pointer and calls Sys.init.
+func (w *Writer) WriteInit() error {
+	w.main.add(instruction{text: "(call $$set (i32.const 0) (i32.const 256))"})
+	return w.WriteCall("Sys.init", 0)
+}
+
+// WriteArithmetic writes the arithmetic command.
+func (w *Writer) WriteArithmetic(operation string) error {
+	if result, ok := unaryOperations[operation]; ok {
+		w.current.add(instruction{text: fmt.Sprintf(";; %s\n(local.set $y (call $$pop))\n(call $$push %s)", operation, result)})
+		return nil
+	}
+
+	result, ok := binaryOperations[operation]
+	if !ok {
+		return fmt.Errorf("unknown arithmetic command %q", operation)
+	}
+
+	w.current.add(instruction{text: fmt.Sprintf(";; %s\n(local.set $y (call $$pop))\n(local.set $x (call $$pop))\n(call $$push %s)", operation, result)})
+	return nil
+}
+
+// address returns the code of the address of the segment at the index
+func (w *Writer) address(segment string, index int) (string, error) {
+	if fixed, ok := fixedSegments[segment]; ok {
+		if index >= fixed[1] {
+			return "", fmt.Errorf("%s index %d is outside of the range 0-%d", segment, index, fixed[1]-1)
+		}
+
+		return fmt.Sprintf("(i32.const %d)", fixed[0]+index), nil
+	}
+
+	if pointer, ok := segments[segment]; ok {
+		return fmt.Sprintf("(i32.add (call $$get (i32.const %d)) (i32.const %d))", pointer, index), nil
+	}
+
+	if segment == "static" {
+		name := fmt.Sprintf("%s.%d", w.filename, index)
+		if _, ok := w.statics[name]; !ok {
+			w.statics[name] = firstStatic + len(w.statics)
+		}
+
+		return fmt.Sprintf("(i32.const %d)", w.statics[name]), nil
+	}
+
+	return "", fmt.Errorf("unknown segment %q", segment)
+}
+
+// WritePush writes the push command.
+func (w *Writer) WritePush(segment string, index int) error {
+	value := fmt.Sprintf("(i32.const %d)", index)
+	if segment != "constant" {
+		address, err := w.address(segment, index)
+		if err != nil {
+			return err
+		}
+		value = fmt.Sprintf("(call $$get %s)", address)
+	}
+
+	w.current.add(instruction{text: fmt.Sprintf(";; push %s %d\n(call $$push %s)", segment, index, value)})
+	return nil
+}
+
+// WritePop writes the pop command.
+func (w *Writer) WritePop(segment string, index int) error {
+	if segment == "constant" {
+		return fmt.Errorf("can't pop to the constant segment")
+	}
+
+	address, err := w.address(segment, index)
+	if err != nil {
+		return err
+	}
+
+	w.current.add(instruction{text: fmt.Sprintf(";; pop %s %d\n(call $$set %s (call $$pop))", segment, index, address)})
+	return nil
+}
+
+// WriteLabel starts the new block of the function. Labels are scoped by the functions,
+// the function argument is ignored.
+func (w *Writer) WriteLabel(label, function string) error {
+	if _, ok := w.current.labels[label]; ok {
+		return fmt.Errorf("label %s is already defined", label)
+	}
+
+	w.current.labels[label] = len(w.current.blocks)
+	w.current.blocks = append(w.current.blocks, &block{label: label})
+	return nil
+}
+
+// WriteGoto writes the goto command. The halt loop, the goto to the label of the current
+// empty block, calls the host.
+func (w *Writer) WriteGoto(label, function string) error {
+	last := len(w.current.blocks) - 1
+	if index, ok := w.current.labels[label]; ok && index == last && len(w.current.blocks[last].instructions) == 0 {
+		w.halts = true
+		w.current.add(instruction{text: fmt.Sprintf(";; goto %s\n(call $$halt)\n(unreachable)", label)})
+		return nil
+	}
+
+	w.current.add(instruction{jump: label})
+	return nil
+}
+
+// WriteIf writes the if-goto command.
+func (w *Writer) WriteIf(label, function string) error {
+	w.current.add(instruction{jump: label, conditional: true})
+	return nil
+}
+
+// WriteFunction starts the new function with the local variables set to 0.
+func (w *Writer) WriteFunction(name string, variables int) error {
+	for _, f := range w.functions {
+		if f.name == name {
+			return fmt.Errorf("function %s is already defined", name)
+		}
+	}
+
+	w.current = newFunction(name, variables)
+	w.functions = append(w.functions, w.current)
+	return nil
+}
+
+// WriteCall writes the call command.
+func (w *Writer) WriteCall(function string, arguments int) error {
+	w.arguments[function] = append(w.arguments[function], arguments)
+	w.current.add(instruction{call: function, arguments: arguments})
+	return nil
+}
+
+// WriteReturn writes the return command.
+func (w *Writer) WriteReturn() error {
+	w.current.add(instruction{text: ";; return\n(call $$return)\n(return)"})
+	return nil
+}
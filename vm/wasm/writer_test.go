@@ -0,0 +1,88 @@
+package wasm
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ProchazkaDavid/nand2tetris/vm/translator"
+)
+
+// translate translates the VM code with the bootstrap code into the WebAssembly module
+func translate(code, filename string) (string, error) {
+	var module strings.Builder
+	vmTranslator := translator.NewBackend(NewWriter(&module))
+
+	if err := vmTranslator.WriteInit(); err != nil {
+		return "", err
+	}
+	if err := vmTranslator.Translate(strings.NewReader(code), filename); err != nil {
+		return "", err
+	}
+	if err := vmTranslator.Finish(); err != nil {
+		return "", err
+	}
+
+	return module.String(), nil
+}
+
+// TestGolden compares the module of testdata/Main.vm with testdata/Main.wat. The program uses
+// the segments, the comparison, the jumps, the call of the defined and the imported function
+// and the halt loop.
+func TestGolden(t *testing.T) {
+	code, err := os.ReadFile("testdata/Main.vm")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := os.ReadFile("testdata/Main.wat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	module, err := translate(string(code), "Main.vm")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if module != string(expected) {
+		t.Errorf("module differs from testdata/Main.wat:\n%s", module)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		err  string
+	}{
+		{
+			name: "undefined label",
+			code: "function Sys.init 0\ngoto NOPE\n",
+			err:  "label NOPE isn't defined in function Sys.init",
+		},
+		{
+			name: "arity of imported function",
+			code: "function Sys.init 0\npush constant 1\ncall A.b 1\npush constant 1\npush constant 2\ncall A.b 2\nlabel END\ngoto END\n",
+			err:  "imported function A.b is called with 1 and 2 arguments",
+		},
+		{
+			name: "duplicate function",
+			code: "function Sys.init 0\nlabel END\ngoto END\nfunction Sys.init 0\n",
+			err:  "function Sys.init is already defined",
+		},
+		{
+			name: "temp index",
+			code: "function Sys.init 0\npush temp 8\n",
+			err:  "temp index 8 is outside of the range 0-7",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := translate(test.code, "Sys.vm"); err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("got error %v, expected %q", err, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,493 @@
+// Package interpreter executes the VM code directly, without the translation into the assembly.
+// The memory layout, the call frames and the bootstrap are the same as of the code
+// generated by the VM translator, so the results can be compared word by word, except
+// the return addresses saved in the call frames, which are indices of the VM commands
+// instead of the ROM addresses.
+package interpreter
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/ProchazkaDavid/nand2tetris/vm/command"
+)
+
+// Memory layout of the VM
+const (
+	// SP is the address of the stack pointer
+	SP = 0
+	// LCL is the address of the base of the local segment
+	LCL = 1
+	// ARG is the address of the base of the argument segment
+	ARG = 2
+	// THIS is the address of the base of the this segment
+	THIS = 3
+	// THAT is the address of the base of the that segment
+	THAT = 4
+	// Temp is the base address of the temp segment
+	Temp = 5
+	// Static is the first address of the static variables
+	Static = 16
+	// StackBase is the address of the bottom of the stack
+	StackBase = 256
+	// HeapBase is the first address of the heap
+	HeapBase = 2048
+	// HeapEnd is the address after the last word of the heap
+	HeapEnd = 0x4000
+	// RAMSize is the size of the RAM including the screen and the keyboard
+	RAMSize = 0x6001
+)
+
+// frameSize is the number of words saved by the call - return address, LCL, ARG, THIS and THAT
+const frameSize = 5
+
+// instruction is the loaded VM command
+type instruction struct {
+	kind command.Type
+	// name is the operation, the segment, the label or the function
+	name  string
+	index int
+	// function is the enclosing function, labels are local to it
+	function string
+	// static is the RAM address of the static variable
+	static   uint16
+	filename string
+	line     int
+}
+
+// Frame represents the function of the call stack.
+type Frame struct {
+	Function  string
+	Arguments int
+	Locals    int
+}
+
+// Machine represents the VM with the loaded program and the RAM.
+type Machine struct {
+	RAM [RAMSize]uint16
+
+	program   []instruction
+	functions map[string]int
+	labels    map[string]int
+	// statics maps the static variables File.index to their RAM addresses
+	statics map[string]uint16
+	frames  []Frame
+	pc      int
+	// halted is set once the program reaches a halt loop
+	halted bool
+}
+
+// New creates a new VM with empty memory and the stack pointer set to the bottom of the stack.
+func New() *Machine {
+	m := &Machine{functions: map[string]int{}, labels: map[string]int{}, statics: map[string]uint16{}}
+	m.RAM[SP] = StackBase
+	return m
+}
+
+// Load appends the VM code read from the input to the program. The filename names the static
+// variables, which are allocated in the order of their first use like by the assembler.
+// The execution starts at the first loaded command unless Bootstrap is called.
+// Invalid commands, duplicate labels and functions are returned at once as the command.ErrorList.
+func (m *Machine) Load(input io.Reader, filename string) error {
+	commands, err := command.Parse(input, filename)
+	if err != nil {
+		return err
+	}
+
+	class := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	function := ""
+
+	var errors command.ErrorList
+	for _, cmd := range commands {
+		inst := instruction{kind: cmd.Type, name: cmd.First, index: cmd.Second, filename: filename, line: cmd.Line, function: function}
+		errorf := func(format string, args ...interface{}) {
+			errors = append(errors, &command.Error{Filename: filename, Line: inst.line, Message: fmt.Sprintf(format, args...)})
+		}
+
+		switch inst.kind {
+		case command.Push, command.Pop:
+			if inst.name == "static" {
+				name := fmt.Sprintf("%s.%d", class, inst.index)
+				if _, ok := m.statics[name]; !ok {
+					m.statics[name] = uint16(Static + len(m.statics))
+				}
+				inst.static = m.statics[name]
+			}
+
+		case command.Label:
+			label := function + "$" + inst.name
+			if _, ok := m.labels[label]; ok {
+				errorf("duplicate label %s in %s", inst.name, function)
+				continue
+			}
+			m.labels[label] = len(m.program)
+
+		case command.Function:
+			if _, ok := m.functions[inst.name]; ok {
+				errorf("duplicate function %s", inst.name)
+				continue
+			}
+			m.functions[inst.name] = len(m.program)
+			function, inst.function = inst.name, inst.name
+		}
+
+		m.program = append(m.program, inst)
+	}
+
+	if err := errors.Err(); err != nil {
+		return err
+	}
+
+	if len(m.statics) > StackBase-Static {
+		return fmt.Errorf("%d static variables exceed the static region %d-%d", len(m.statics), Static, StackBase-1)
+	}
+
+	return nil
+}
+
+// Bootstrap sets the stack pointer to the bottom of the stack and calls Sys.init
+// like the bootstrap code of the VM translator. The program halts when Sys.init returns.
+func (m *Machine) Bootstrap() error {
+	if _, ok := m.functions["Sys.init"]; !ok {
+		return fmt.Errorf("undefined function Sys.init")
+	}
+
+	m.RAM[SP] = StackBase
+	m.halted = false
+	return m.call("Sys.init", 0, len(m.program))
+}
+
+// Halted returns true if the program reached a halt loop (label X, goto X), ran past its last
+// command or returned from the bootstrap call.
+func (m *Machine) Halted() bool { return m.halted || m.pc >= len(m.program) }
+
+// Run executes at most steps commands. The execution stops earlier if the program halts.
+// Returns number of executed commands.
+func (m *Machine) Run(steps int) (int, error) {
+	executed := 0
+
+	for ; executed < steps && !m.Halted(); executed++ {
+		if err := m.Step(); err != nil {
+			return executed, err
+		}
+	}
+
+	return executed, nil
+}
+
+// Step executes a single command. Does nothing if the program is halted.
+// Runtime errors are reported at the line of the command.
+func (m *Machine) Step() error {
+	if m.Halted() {
+		return nil
+	}
+
+	inst := m.program[m.pc]
+	if err := m.execute(inst); err != nil {
+		return &command.Error{Filename: inst.filename, Line: inst.line, Message: err.Error()}
+	}
+
+	return nil
+}
+
+// execute executes the instruction and moves to the next one
+func (m *Machine) execute(inst instruction) error {
+	next := m.pc + 1
+
+	switch inst.kind {
+	case command.Arithmetic:
+		if err := m.arithmetic(inst.name); err != nil {
+			return err
+		}
+
+	case command.Push:
+		value := uint16(inst.index)
+		if inst.name != "constant" {
+			address, err := m.address(inst)
+			if err != nil {
+				return err
+			}
+			value = m.RAM[address]
+		}
+
+		if err := m.push(value); err != nil {
+			return err
+		}
+
+	case command.Pop:
+		address, err := m.address(inst)
+		if err != nil {
+			return err
+		}
+
+		value, err := m.pop()
+		if err != nil {
+			return err
+		}
+		m.RAM[address] = value
+
+	case command.Goto, command.If:
+		if inst.kind == command.If {
+			value, err := m.pop()
+			if err != nil {
+				return err
+			}
+
+			if value == 0 {
+				break
+			}
+		}
+
+		target, ok := m.labels[inst.function+"$"+inst.name]
+		if !ok {
+			return fmt.Errorf("undefined label %s in %s", inst.name, inst.function)
+		}
+		next = target
+		m.halted = inst.kind == command.Goto && m.isHaltLoop(target)
+
+	case command.Function:
+		if len(m.frames) > 0 {
+			m.frames[len(m.frames)-1].Locals = inst.index
+		}
+
+		for i := 0; i < inst.index; i++ {
+			if err := m.push(0); err != nil {
+				return err
+			}
+		}
+
+	case command.Call:
+		if err := m.call(inst.name, inst.index, next); err != nil {
+			return err
+		}
+		return nil
+
+	case command.Return:
+		return m.ret()
+	}
+
+	m.pc = next
+	return nil
+}
+
+// isHaltLoop checks if only labels are between the target and the current command
+func (m *Machine) isHaltLoop(target int) bool {
+	for target < m.pc && m.program[target].kind == command.Label {
+		target++
+	}
+
+	return target == m.pc
+}
+
+// arithmetic executes the arithmetic or logical operation on the top of the stack
+func (m *Machine) arithmetic(operation string) error {
+	y, err := m.pop()
+	if err != nil {
+		return err
+	}
+
+	var result uint16
+	switch operation {
+	case "neg":
+		result = -y
+	case "not":
+		result = ^y
+	default:
+		x, err := m.pop()
+		if err != nil {
+			return err
+		}
+
+		result = binary(operation, x, y)
+	}
+
+	return m.push(result)
+}
+
+// binary returns the result of the binary operation, true is -1 and false is 0
+func binary(operation string, x, y uint16) uint16 {
+	truth := func(condition bool) uint16 {
+		if condition {
+			return 0xFFFF
+		}
+		return 0
+	}
+
+	switch operation {
+	case "add":
+		return x + y
+	case "sub":
+		return x - y
+	case "eq":
+		return truth(x == y)
+	case "gt":
+		return truth(int16(x) > int16(y))
+	case "lt":
+		return truth(int16(x) < int16(y))
+	case "and":
+		return x & y
+	default:
+		return x | y
+	}
+}
+
+// address returns the RAM address of the segment word of the push or pop instruction
+func (m *Machine) address(inst instruction) (uint16, error) {
+	var address int
+
+	switch inst.name {
+	case "local":
+		address = int(m.RAM[LCL]) + inst.index
+	case "argument":
+		address = int(m.RAM[ARG]) + inst.index
+	case "this":
+		address = int(m.RAM[THIS]) + inst.index
+	case "that":
+		address = int(m.RAM[THAT]) + inst.index
+	case "pointer":
+		address = THIS + inst.index
+	case "temp":
+		address = Temp + inst.index
+	case "static":
+		address = int(inst.static)
+	}
+
+	if address >= RAMSize {
+		return 0, fmt.Errorf("%s %d refers to RAM[%d] outside of the memory", inst.name, inst.index, address)
+	}
+
+	return uint16(address), nil
+}
+
+// push pushes the value on the top of the stack
+func (m *Machine) push(value uint16) error {
+	if int(m.RAM[SP]) >= HeapBase {
+		return fmt.Errorf("stack overflow")
+	}
+
+	m.RAM[m.RAM[SP]] = value
+	m.RAM[SP]++
+	return nil
+}
+
+// pop removes the value from the top of the stack
+func (m *Machine) pop() (uint16, error) {
+	if m.RAM[SP] <= StackBase {
+		return 0, fmt.Errorf("stack underflow")
+	}
+
+	m.RAM[SP]--
+	return m.RAM[m.RAM[SP]], nil
+}
+
+// call saves the frame of the caller and jumps to the function, the returnAddress
+// is the index of the command executed after the return
+func (m *Machine) call(function string, arguments, returnAddress int) error {
+	target, ok := m.functions[function]
+	if !ok {
+		return fmt.Errorf("undefined function %s", function)
+	}
+
+	for _, value := range []uint16{uint16(returnAddress), m.RAM[LCL], m.RAM[ARG], m.RAM[THIS], m.RAM[THAT]} {
+		if err := m.push(value); err != nil {
+			return err
+		}
+	}
+
+	m.RAM[ARG] = m.RAM[SP] - frameSize - uint16(arguments)
+	m.RAM[LCL] = m.RAM[SP]
+	m.frames = append(m.frames, Frame{Function: function, Arguments: arguments})
+	m.pc = target
+	return nil
+}
+
+// ret returns the value on the top of the stack to the caller and restores its frame
+func (m *Machine) ret() error {
+	frame := m.RAM[LCL]
+	if frame < StackBase+frameSize {
+		return fmt.Errorf("return without the call frame")
+	}
+
+	returnAddress := m.RAM[frame-5]
+
+	value, err := m.pop()
+	if err != nil {
+		return err
+	}
+
+	m.RAM[m.RAM[ARG]] = value
+	m.RAM[SP] = m.RAM[ARG] + 1
+	m.RAM[THAT] = m.RAM[frame-1]
+	m.RAM[THIS] = m.RAM[frame-2]
+	m.RAM[ARG] = m.RAM[frame-3]
+	m.RAM[LCL] = m.RAM[frame-4]
+
+	if len(m.frames) > 0 {
+		m.frames = m.frames[:len(m.frames)-1]
+	}
+
+	m.pc = int(returnAddress)
+	return nil
+}
+
+// Position returns the file and the line of the next command, or false if the program is halted.
+func (m *Machine) Position() (filename string, line int, ok bool) {
+	if m.Halted() {
+		return "", 0, false
+	}
+
+	inst := m.program[m.pc]
+	return inst.filename, inst.line, true
+}
+
+// CallStack returns the called functions, the innermost last.
+func (m *Machine) CallStack() []Frame { return append([]Frame(nil), m.frames...) }
+
+// Stack returns the words of the stack from its bottom to the top.
+func (m *Machine) Stack() []uint16 {
+	if m.RAM[SP] <= StackBase || int(m.RAM[SP]) > RAMSize {
+		return nil
+	}
+
+	return append([]uint16(nil), m.RAM[StackBase:m.RAM[SP]]...)
+}
+
+// Heap returns the words of the heap.
+func (m *Machine) Heap() []uint16 { return append([]uint16(nil), m.RAM[HeapBase:HeapEnd]...) }
+
+// Segment returns count words of the segment starting at its index 0
+// as they are seen by the push command.
+func (m *Machine) Segment(segment string, count int) ([]uint16, error) {
+	if segment == "static" || segment == "constant" {
+		return nil, fmt.Errorf("segment %q can't be listed", segment)
+	}
+
+	words := make([]uint16, 0, count)
+	for index := 0; index < count; index++ {
+		if err := command.CheckSegment(segment, index, false); err != nil {
+			return nil, err
+		}
+
+		inst := instruction{kind: command.Push, name: segment, index: index}
+
+		address, err := m.address(inst)
+		if err != nil {
+			return nil, err
+		}
+		words = append(words, m.RAM[address])
+	}
+
+	return words, nil
+}
+
+// Statics returns the values of the static variables by their names File.index.
+func (m *Machine) Statics() map[string]uint16 {
+	values := make(map[string]uint16, len(m.statics))
+	for name, address := range m.statics {
+		values[name] = m.RAM[address]
+	}
+
+	return values
+}
@@ -0,0 +1,273 @@
+package interpreter
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ProchazkaDavid/nand2tetris/assembler/asm"
+	"github.com/ProchazkaDavid/nand2tetris/emulator/computer"
+	"github.com/ProchazkaDavid/nand2tetris/vm/translator"
+)
+
+// examples is the folder with the examples of the VM translator
+const examples = "../../vm/examples"
+
+// load loads the .vm file or the .vm files of the folder, folders are bootstrapped
+func load(t *testing.T, path string) *Machine {
+	t.Helper()
+
+	files := []string{path}
+	if !strings.HasSuffix(path, ".vm") {
+		files, _ = filepath.Glob(filepath.Join(path, "*.vm"))
+	}
+
+	m := New()
+	for _, filename := range files {
+		f, err := os.Open(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = m.Load(f, filename)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(files) > 1 {
+		if err := m.Bootstrap(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return m
+}
+
+// signed converts the words to the unsigned RAM values
+func signed(value int) uint16 { return uint16(int16(value)) }
+
+func TestExamples(t *testing.T) {
+	tests := []struct {
+		path     string
+		ram      map[int]int
+		expected map[int]int
+	}{
+		{
+			path:     "BasicLoop.vm",
+			ram:      map[int]int{0: 256, 1: 300, 2: 400, 400: 3},
+			expected: map[int]int{0: 257, 256: 6},
+		},
+		{
+			path:     "FibonacciSeries.vm",
+			ram:      map[int]int{0: 256, 1: 300, 2: 400, 400: 6, 401: 3000},
+			expected: map[int]int{3000: 0, 3001: 1, 3002: 1, 3003: 2, 3004: 3, 3005: 5},
+		},
+		{
+			path: "SimpleFunction.vm",
+			ram: map[int]int{0: 317, 1: 317, 2: 310, 3: 3000, 4: 4000, 310: 1234, 311: 37,
+				312: 1000, 313: 305, 314: 300, 315: 3010, 316: 4010},
+			expected: map[int]int{0: 311, 1: 305, 2: 300, 3: 3010, 4: 4010, 310: 1196},
+		},
+		{
+			path:     "FibonacciElement",
+			expected: map[int]int{0: 262, 261: 3},
+		},
+		{
+			path:     "StaticsTest",
+			expected: map[int]int{0: 263, 261: -2, 262: 8},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			m := load(t, filepath.Join(examples, test.path))
+			for address, value := range test.ram {
+				m.RAM[address] = signed(value)
+			}
+
+			if _, err := m.Run(10000); err != nil {
+				t.Fatal(err)
+			}
+
+			if !m.Halted() {
+				t.Fatal("the program didn't halt")
+			}
+
+			for address, value := range test.expected {
+				if m.RAM[address] != signed(value) {
+					t.Errorf("RAM[%d] = %d, expected %d", address, int16(m.RAM[address]), value)
+				}
+			}
+		})
+	}
+}
+
+func TestState(t *testing.T) {
+	m := load(t, filepath.Join(examples, "StaticsTest"))
+	if _, err := m.Run(10000); err != nil {
+		t.Fatal(err)
+	}
+
+	statics := m.Statics()
+	expected := map[string]uint16{"Class1.0": 6, "Class1.1": 8, "Class2.0": 23, "Class2.1": 15}
+	for name, value := range expected {
+		if statics[name] != value {
+			t.Errorf("static %s = %d, expected %d", name, statics[name], value)
+		}
+	}
+
+	frames := m.CallStack()
+	if len(frames) != 1 || frames[0].Function != "Sys.init" {
+		t.Errorf("call stack %+v, expected Sys.init", frames)
+	}
+
+	// The frame of Sys.init is followed by the results of the calls
+	stack := m.Stack()
+	if len(stack) != frameSize+2 || int16(stack[frameSize]) != -2 || stack[frameSize+1] != 8 {
+		t.Errorf("stack %v, expected the frame and [-2 8]", stack)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		err  string
+	}{
+		{"stack underflow", "push constant 1\nadd\n", "Test.vm:2: stack underflow"},
+		{"undefined function", "push constant 1\ncall Main.missing 1\n", "Test.vm:2: undefined function Main.missing"},
+		{"undefined label", "function Main.main 0\ngoto END\n", "Test.vm:2: undefined label END in Main.main"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := New()
+			if err := m.Load(strings.NewReader(test.code), "Test.vm"); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := m.Run(100); err == nil || err.Error() != test.err {
+				t.Errorf("got error %v, expected %s", err, test.err)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		err  string
+	}{
+		{
+			name: "invalid commands",
+			code: "push temp 8\npop constant 1\npush constant 1\nfoo\n",
+			err: "Test.vm:1: temp index 8 is outside of the range 0-7\n" +
+				"Test.vm:2: can't pop to the constant segment\n" +
+				"Test.vm:4: unknown command \"foo\"",
+		},
+		{
+			name: "duplicates",
+			code: "function Main.f 0\nlabel L\nlabel L\nfunction Main.f 0\n",
+			err:  "Test.vm:3: duplicate label L in Main.f\nTest.vm:4: duplicate function Main.f",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := New().Load(strings.NewReader(test.code), "Test.vm"); err == nil || err.Error() != test.err {
+				t.Errorf("got error %v, expected %s", err, test.err)
+			}
+		})
+	}
+}
+
+func TestSegment(t *testing.T) {
+	m := New()
+	m.RAM[5], m.RAM[12] = 3, 4
+
+	if words, err := m.Segment("temp", 8); err != nil || words[0] != 3 || words[7] != 4 {
+		t.Errorf("temp segment %v, %v, expected 3 at 0 and 4 at 7", words, err)
+	}
+
+	for segment, expected := range map[string]string{
+		"temp":   "temp index 8 is outside of the range 0-7",
+		"static": `segment "static" can't be listed`,
+		"heap":   `unknown segment "heap"`,
+	} {
+		if _, err := m.Segment(segment, 9); err == nil || err.Error() != expected {
+			t.Errorf("segment %s error %v, expected %s", segment, err, expected)
+		}
+	}
+}
+
+// returnAddresses returns the addresses of the return addresses saved in the frames of the call stack
+func returnAddresses(ram []uint16) map[int]bool {
+	addresses := map[int]bool{}
+	for frame := int(ram[LCL]); frame >= StackBase+frameSize && frame <= int(ram[SP]); frame = int(ram[frame-4]) {
+		addresses[frame-frameSize] = true
+	}
+
+	return addresses
+}
+
+// TestTranslatedProgram compares the RAM of the interpreter with the RAM of the translated
+// program executed by the CPU emulator, except the saved return addresses and the registers
+// R13-R15 used by the translated code
+func TestTranslatedProgram(t *testing.T) {
+	for _, example := range []string{"FibonacciElement", "StaticsTest"} {
+		t.Run(example, func(t *testing.T) {
+			m := load(t, filepath.Join(examples, example))
+			if _, err := m.Run(10000); err != nil {
+				t.Fatal(err)
+			}
+
+			var assembly bytes.Buffer
+			vmTranslator := translator.New(&assembly, example+".asm")
+			if err := vmTranslator.WriteInit(); err != nil {
+				t.Fatal(err)
+			}
+
+			filenames, _ := filepath.Glob(filepath.Join(examples, example, "*.vm"))
+			for _, filename := range filenames {
+				code, err := os.ReadFile(filename)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if err := vmTranslator.Translate(bytes.NewReader(code), filename); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			program, err := asm.Assemble(&assembly, example+".asm", asm.Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c := computer.New()
+			if err := c.LoadWords(program.Words); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := c.Run(100000); err != nil || !c.Halted() {
+				t.Fatalf("the translated program didn't halt: %v", err)
+			}
+
+			skipped := returnAddresses(m.RAM[:])
+			if len(skipped) == 0 {
+				t.Error("expected the frame of Sys.init")
+			}
+
+			skipped[13], skipped[14], skipped[15] = true, true, true
+			for address := 0; address < int(m.RAM[SP]); address++ {
+				if !skipped[address] && c.RAM[address] != m.RAM[address] {
+					t.Errorf("RAM[%d] = %d, the translated program has %d", address, m.RAM[address], c.RAM[address])
+				}
+			}
+		})
+	}
+}
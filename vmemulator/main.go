@@ -0,0 +1,179 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ProchazkaDavid/nand2tetris/vmemulator/interpreter"
+)
+
+// ramValues collects the initial RAM values given as address=value
+type ramValues map[uint16]uint16
+
+func (r ramValues) String() string { return fmt.Sprint(map[uint16]uint16(r)) }
+
+func (r ramValues) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("expected address=value, got %q", value)
+	}
+
+	address, err := strconv.ParseUint(parts[0], 0, 16)
+	if err != nil || address >= interpreter.RAMSize {
+		return fmt.Errorf("invalid address %q", parts[0])
+	}
+
+	word, err := strconv.ParseInt(parts[1], 0, 32)
+	if err != nil || word < -0x8000 || word > 0xFFFF {
+		return fmt.Errorf("invalid value %q", parts[1])
+	}
+
+	r[uint16(address)] = uint16(word)
+	return nil
+}
+
+func main() {
+	steps := flag.Int("steps", 1000000, "maximum number of executed VM commands")
+	ram := ramValues{}
+	flag.Var(ram, "ram", "initial RAM value as address=value, can be repeated")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		log.Fatalln("expected one argument - file or folder")
+	}
+
+	if err := run(flag.Arg(0), *steps, ram); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// run executes given file or folder and prints the final state of the VM.
+// Folders are started by the bootstrap call of Sys.init, single files
+// from their first command.
+func run(path string, steps int, ram ramValues) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("can't get info about the input: %w", err)
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		files, err = filepath.Glob(filepath.Join(path, "*.vm"))
+		if err != nil {
+			return fmt.Errorf("can't get input files: %w", err)
+		}
+	}
+
+	vm := interpreter.New()
+	for _, file := range files {
+		if err := loadFile(vm, file); err != nil {
+			return err
+		}
+	}
+
+	if info.IsDir() {
+		if err := vm.Bootstrap(); err != nil {
+			return err
+		}
+	}
+
+	for address, value := range ram {
+		vm.RAM[address] = value
+	}
+
+	executed, err := vm.Run(steps)
+	if err != nil {
+		return err
+	}
+
+	return printState(vm, executed)
+}
+
+// loadFile loads the .vm file into the VM
+func loadFile(vm *interpreter.Machine, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return vm.Load(f, file)
+}
+
+// segmentView is the segment printed with the given number of words
+type segmentView struct {
+	name  string
+	count int
+}
+
+// printState prints the call stack, the stack, the segments of the current function,
+// the static variables and the non-zero words of the heap
+func printState(vm *interpreter.Machine, executed int) error {
+	status := "halted"
+	if filename, line, ok := vm.Position(); ok {
+		status = fmt.Sprintf("running at %s:%d", filename, line)
+	}
+	fmt.Printf("steps: %d (%s)\n", executed, status)
+
+	frames := vm.CallStack()
+	names := make([]string, 0, len(frames))
+	for _, frame := range frames {
+		names = append(names, frame.Function)
+	}
+	fmt.Printf("call stack: %s\n", strings.Join(names, " > "))
+
+	fmt.Printf("SP: %d LCL: %d ARG: %d THIS: %d THAT: %d\n", vm.RAM[interpreter.SP], vm.RAM[interpreter.LCL],
+		vm.RAM[interpreter.ARG], vm.RAM[interpreter.THIS], vm.RAM[interpreter.THAT])
+	fmt.Printf("stack: %s\n", words(vm.Stack()))
+
+	segments := []segmentView{{"temp", 8}, {"pointer", 2}}
+	if len(frames) > 0 {
+		frame := frames[len(frames)-1]
+		segments = append([]segmentView{{"local", frame.Locals}, {"argument", frame.Arguments}}, segments...)
+	}
+
+	for _, segment := range segments {
+		values, err := vm.Segment(segment.name, segment.count)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s: %s\n", segment.name, words(values))
+	}
+
+	statics := vm.Statics()
+	names = make([]string, 0, len(statics))
+	for name := range statics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Println("static:")
+	for _, name := range names {
+		fmt.Printf("  %s %d\n", name, int16(statics[name]))
+	}
+
+	fmt.Println("heap:")
+	for i, value := range vm.Heap() {
+		if value != 0 {
+			fmt.Printf("  [%d] %d\n", interpreter.HeapBase+i, int16(value))
+		}
+	}
+
+	return nil
+}
+
+// words returns the words as signed numbers
+func words(values []uint16) string {
+	numbers := make([]string, 0, len(values))
+	for _, value := range values {
+		numbers = append(numbers, strconv.Itoa(int(int16(value))))
+	}
+
+	return "[" + strings.Join(numbers, " ") + "]"
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/ProchazkaDavid/nand2tetris/vm/callgraph"
+	"github.com/ProchazkaDavid/nand2tetris/vm/command"
+)
+
+func main() {
+	format := flag.String("format", "dot", "output format: dot or json")
+	classes := flag.Bool("classes", false, "collapse the functions to their classes")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		log.Fatalln("expected one argument - file or folder")
+	}
+
+	if *format != "dot" && *format != "json" {
+		log.Fatalf("unknown format %q, expected dot or json\n", *format)
+	}
+
+	if err := run(flag.Arg(0), *format, *classes); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// run prints the call graph of given file or folder in the format
+func run(path string, format string, classes bool) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("can't get info about the input: %w", err)
+	}
+
+	filenames := []string{path}
+	if info.IsDir() {
+		filenames, err = filepath.Glob(filepath.Join(path, "*.vm"))
+		if err != nil {
+			return fmt.Errorf("can't get input files: %w", err)
+		}
+	}
+
+	var files [][]command.Command
+	var errors command.ErrorList
+	for _, filename := range filenames {
+		commands, err := parseFile(filename)
+		if list, ok := err.(command.ErrorList); ok {
+			errors = append(errors, list...)
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		files = append(files, commands)
+	}
+
+	if err := errors.Err(); err != nil {
+		return err
+	}
+
+	graph := callgraph.New(files)
+	if classes {
+		graph = graph.Classes()
+	}
+
+	if format == "json" {
+		return graph.WriteJSON(os.Stdout)
+	}
+
+	return graph.WriteDOT(os.Stdout)
+}
+
+// parseFile parses the .vm file
+func parseFile(filename string) ([]command.Command, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return command.Parse(f, filename)
+}
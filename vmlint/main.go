@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/ProchazkaDavid/nand2tetris/vm/command"
+	"github.com/ProchazkaDavid/nand2tetris/vm/verifier"
+)
+
+func main() {
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		log.Fatalln("expected one argument - file or folder")
+	}
+
+	if err := run(flag.Arg(0)); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// run verifies given file or folder, errors of all files are reported at once
+func run(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("can't get info about the input: %w", err)
+	}
+
+	filenames := []string{path}
+	if info.IsDir() {
+		filenames, err = filepath.Glob(filepath.Join(path, "*.vm"))
+		if err != nil {
+			return fmt.Errorf("can't get input files: %w", err)
+		}
+	}
+
+	var files []verifier.File
+	var errors command.ErrorList
+	for _, filename := range filenames {
+		commands, err := parseFile(filename)
+		if list, ok := err.(command.ErrorList); ok {
+			errors = append(errors, list...)
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		files = append(files, verifier.File{Filename: filename, Commands: commands})
+	}
+
+	if err := errors.Err(); err != nil {
+		return err
+	}
+
+	return verifier.Verify(files).Err()
+}
+
+// parseFile parses the .vm file
+func parseFile(filename string) ([]command.Command, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return command.Parse(f, filename)
+}